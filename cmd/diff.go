@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cortex-client/pkg/client"
+)
+
+// runDiff runs a query against several backends and reports how their
+// results differ from the first one's. The exit code is 1 when they differ
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("cortex-client diff", flag.ContinueOnError)
+	var common metadataFlags
+	common.register(flags)
+	query := flags.String("query", "", "PromQL query run on every backend")
+	evalTime := flags.String("time", "", "Evaluation time of an instant query (RFC3339, unix timestamp or now-5m), defaults to now")
+	start := flags.String("start", "", "Start of a range query (RFC3339, unix timestamp or now-1h); enables range mode")
+	end := flags.String("end", "", "End of a range query (RFC3339, unix timestamp or now-5m), defaults to now")
+	step := flags.Duration("step", 15*time.Second, "Resolution step of a range query")
+	tolerance := flags.String("tolerance", "", "How far values may differ to match: an absolute difference such as 0.5 or a relative one such as 1%")
+	output := flags.String("output", "text", "Output format: text or json")
+	if err := flags.Parse(args); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		return 2
+	}
+
+	if *query == "" {
+		fmt.Println("Please provide the query to compare with --query")
+		return 2
+	}
+	if *output != "text" && *output != "json" {
+		fmt.Printf("Invalid --output %q, expected text or json\n", *output)
+		return 2
+	}
+	tol, err := client.ParseTolerance(*tolerance)
+	if err != nil {
+		fmt.Printf("Invalid --tolerance: %v\n", err)
+		return 2
+	}
+
+	c, q, code := common.client()
+	if code != 0 {
+		return code
+	}
+
+	data := client.QueryData{
+		Query:           *query,
+		Backends:        q.Backends,
+		PartialResponse: q.PartialResponse,
+		Timeout:         q.Timeout,
+		BackendTimeout:  q.BackendTimeout,
+		Tolerance:       tol,
+	}
+	now := time.Now()
+	if *evalTime != "" {
+		if data.Time, err = parseTime(*evalTime, now); err != nil {
+			fmt.Printf("Invalid --time: %v\n", err)
+			return 2
+		}
+	}
+	if *start != "" {
+		if data.Start, err = parseTime(*start, now); err != nil {
+			fmt.Printf("Invalid --start: %v\n", err)
+			return 2
+		}
+		data.End, data.Step = now, *step
+		if *end != "" {
+			if data.End, err = parseTime(*end, now); err != nil {
+				fmt.Printf("Invalid --end: %v\n", err)
+				return 2
+			}
+		}
+	}
+
+	report, err := c.Diff(context.Background(), data)
+	if err != nil {
+		fmt.Printf("Error comparing backends: %v\n", err)
+		return 1
+	}
+
+	if *output == "json" {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Printf("Error encoding report: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(b))
+	} else {
+		writeDiff(os.Stdout, report)
+	}
+	if report.Differ() {
+		return 1
+	}
+	return 0
+}
+
+// writeDiff renders a diff report, one line per difference
+func writeDiff(out io.Writer, report *client.DiffReport) {
+	for _, w := range report.Warnings {
+		fmt.Fprintf(out, "Warning: %s\n", w)
+	}
+	for _, b := range report.Matching {
+		fmt.Fprintf(out, "%s matches %s\n", b, report.Reference)
+	}
+	for _, d := range report.Disagreements {
+		writeDifferences(out, d.Backend, d.Reference, d.Differences)
+	}
+	compared := len(report.Matching) + len(report.Disagreements)
+	if report.Differ() {
+		fmt.Fprintf(out, "%d of %d backends differ from %s\n", len(report.Disagreements), compared, report.Reference)
+	} else {
+		fmt.Fprintf(out, "All %d backends match %s\n", compared, report.Reference)
+	}
+}
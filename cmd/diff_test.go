@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cortex-client/pkg/client"
+)
+
+func TestRunCLI_Diff(t *testing.T) {
+	server := func(result string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":` + result + `}}`)); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		}))
+	}
+	a := server(`[{"metric":{"job":"a"},"value":[1700000000,"1"]},{"metric":{"job":"b"},"value":[1700000000,"2"]}]`)
+	defer a.Close()
+	b := server(`[{"metric":{"job":"a"},"value":[1700000000,"1.5"]},{"metric":{"job":"c"},"value":[1700000000,"2"]}]`)
+	defer b.Close()
+	backends := "--backends=" + a.URL + "," + b.URL
+
+	out, _ := captureOutput(func() {
+		if code := RunCLI([]string{"diff", backends, "--query=up"}); code != 1 {
+			t.Errorf("expected exit code 1, got %d", code)
+		}
+	})
+	for _, want := range []string{
+		b.URL + " differs from " + a.URL + " (1 missing, 1 extra, 1 value):",
+		`  value of {job="a"} at `,
+		`is 1.5, expected 1`,
+		`  missing series {job="b"}`,
+		`  extra series {job="c"}`,
+		"1 of 1 backends differ from " + a.URL,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the diff, got:\n%s", want, out)
+		}
+	}
+
+	out, _ = captureOutput(func() {
+		if code := RunCLI([]string{"diff", "--backends=" + a.URL + "," + a.URL, "--query=up", "--output=json"}); code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	var report client.DiffReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("expected a JSON report, got %v:\n%s", err, out)
+	}
+	if report.Reference != a.URL || len(report.Matching) != 1 || report.Differ() {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	for _, args := range [][]string{
+		{"diff", backends},
+		{"diff", backends, "--query=up", "--output=yaml"},
+		{"diff", backends, "--query=up", "--tolerance=-1"},
+	} {
+		_, _ = captureOutput(func() {
+			if code := RunCLI(args); code != 2 {
+				t.Errorf("expected exit code 2 for %v, got %d", args, code)
+			}
+		})
+	}
+}
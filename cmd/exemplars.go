@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// runExemplars lists the exemplars of the selected series with their trace
+// IDs, one row per exemplar
+func runExemplars(args []string) int {
+	flags := flag.NewFlagSet("cortex-client exemplars", flag.ContinueOnError)
+	var common metadataFlags
+	common.register(flags)
+	query := flags.String("query", "", "PromQL query selecting the series whose exemplars are listed")
+	start := flags.String("start", "now-1h", "Start of the time range searched (RFC3339, unix timestamp or now-1h)")
+	end := flags.String("end", "now", "End of the time range searched (RFC3339, unix timestamp or now-5m)")
+	var traceIDLabels stringList
+	flags.Var(&traceIDLabels, "trace-id-label", "Exemplar label holding the trace ID, may be repeated (default trace_id, traceID, traceId)")
+	if err := flags.Parse(args); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		return 2
+	}
+
+	if *query == "" {
+		fmt.Println("Please provide the series to look up with --query")
+		return 2
+	}
+
+	c, q, code := common.client()
+	if code != 0 {
+		return code
+	}
+
+	var err error
+	now := time.Now()
+	if q.Start, err = parseTime(*start, now); err != nil {
+		fmt.Printf("Invalid --start: %v\n", err)
+		return 2
+	}
+	if q.End, err = parseTime(*end, now); err != nil {
+		fmt.Printf("Invalid --end: %v\n", err)
+		return 2
+	}
+
+	resp, err := c.Exemplars(context.Background(), *query, q)
+	if err != nil {
+		fmt.Printf("Error listing exemplars: %v\n", err)
+		return 1
+	}
+
+	for _, w := range resp.Warnings {
+		fmt.Printf("Warning: %s\n", w)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tTRACE ID\tVALUE\tSERIES")
+	for _, s := range resp.Data {
+		for _, e := range s.Exemplars {
+			fmt.Fprintf(w, "%s\t%s\t%g\t%s\n", e.Time().UTC().Format(time.RFC3339Nano), e.TraceID(traceIDLabels...), e.V, s.SeriesLabels)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Printf("Error writing exemplars: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunCLI_Exemplars(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":[{"seriesLabels":{"job":"api"},"exemplars":[{"labels":{"trace_id":"4bf92f3577b34da6"},"value":"0.25","timestamp":1700000000}]}]}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	out, _ := captureOutput(func() {
+		code := RunCLI([]string{"exemplars", "--backends=" + ts.URL, "--query=latency_bucket"})
+		if code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	if !strings.Contains(out, "2023-11-14T22:13:20Z  4bf92f3577b34da6  0.25   {job=\"api\"}") {
+		t.Errorf("expected exemplar row with trace ID, got:\n%s", out)
+	}
+}
+
+func TestRunCLI_ExemplarsRequiresQuery(t *testing.T) {
+	out, _ := captureOutput(func() {
+		if code := RunCLI([]string{"exemplars", "--backends=http://localhost:9090"}); code != 2 {
+			t.Errorf("expected exit code 2, got %d", code)
+		}
+	})
+	if !strings.Contains(out, "--query") {
+		t.Errorf("expected usage hint, got: %s", out)
+	}
+}
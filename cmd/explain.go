@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/cortex-client/pkg/client"
+)
+
+// writeExplain renders the routing decision of a query, one row per backend
+func writeExplain(out io.Writer, decisions []client.RouteDecision) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	queried := 0
+	fmt.Fprintln(w, "BACKEND\tQUERIED\tRANGE\tREASON")
+	for _, d := range decisions {
+		answer := "no"
+		if d.Queried {
+			answer = "yes"
+			queried++
+		}
+		span := "-"
+		if d.Start != nil && d.End != nil {
+			span = formatReportTime(*d.Start) + " to " + formatReportTime(*d.End)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Backend, answer, span, d.Reason)
+	}
+	fmt.Fprintf(w, "\n%d of %d backends queried\n", queried, len(decisions))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCLI_Explain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backends.yaml")
+	content := "version: 1\n" +
+		"backends:\n" +
+		"  - {name: eu, url: http://eu:9090, labels: {cluster: eu-1}}\n" +
+		"  - {name: us, url: http://us:9090, labels: {cluster: us-1}}\n" +
+		"  - {name: history, url: http://history:9090, time_window: {min_age: 2h}}\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write backends file: %v", err)
+	}
+
+	// nothing listens on the backends, so a query that ran would fail
+	out, _ := captureOutput(func() {
+		if code := RunCLI([]string{"--backends-file=" + path, `--query=up{cluster="eu-1"}`, "--start=now-6h", "--explain"}); code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	for _, want := range []string{
+		"BACKEND  QUERIED  RANGE",
+		`eu       yes      -`,
+		`us       no       -`,
+		`external labels {cluster="us-1"} do not match cluster="eu-1"`,
+		"time window holds part of the range, no external labels",
+		"2 of 3 backends queried",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the explanation, got:\n%s", want, out)
+		}
+	}
+}
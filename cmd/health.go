@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cortex-client/pkg/client"
+)
+
+// runHealth checks the readiness and build info of every selected backend and
+// fails when fewer than --min-ready of them are ready
+func runHealth(args []string) int {
+	flags := flag.NewFlagSet("cortex-client health", flag.ContinueOnError)
+	var common metadataFlags
+	common.register(flags)
+	minReady := flags.Int("min-ready", 0, "Number of backends that must be ready, 0 means all of them")
+	if err := flags.Parse(args); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		return 2
+	}
+	if *minReady < 0 {
+		fmt.Println("Invalid --min-ready: must not be negative")
+		return 2
+	}
+
+	c, q, code := common.client()
+	if code != 0 {
+		return code
+	}
+
+	ctx := context.Background()
+	if q.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.Timeout)
+		defer cancel()
+	}
+	statuses := c.CheckHealth(ctx, q.Backends...)
+	ready := writeHealth(os.Stdout, statuses)
+	want := *minReady
+	if want == 0 {
+		want = len(statuses)
+	}
+	if ready < want {
+		fmt.Printf("Only %d backends ready, %d required\n", ready, want)
+		return 1
+	}
+	return 0
+}
+
+// writeHealth renders the health of every backend and returns how many of
+// them are ready
+func writeHealth(out io.Writer, statuses []client.HealthStatus) int {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	ready := 0
+	fmt.Fprintln(w, "BACKEND\tSTATE\tVERSION\tLATENCY\tERROR")
+	for _, s := range statuses {
+		if s.State == client.HealthReady {
+			ready++
+		}
+		version := "-"
+		if s.BuildInfo != nil && s.BuildInfo.Version != "" {
+			version = s.BuildInfo.Version
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Backend, s.State, version, s.Latency.Round(time.Millisecond), s.Error)
+	}
+	fmt.Fprintf(w, "\n%d of %d backends ready\n", ready, len(statuses))
+	return ready
+}
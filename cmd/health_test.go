@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCLI_Health(t *testing.T) {
+	ready := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/status/buildinfo" {
+			w.Header().Set("Content-Type", "application/json")
+			if _, err := w.Write([]byte(`{"status":"success","data":{"version":"2.53.0"}}`)); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		}
+	}))
+	defer ready.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	backends := "--backends=" + ready.URL + "," + down.URL
+
+	out, _ := captureOutput(func() {
+		if code := RunCLI([]string{"health", backends, "--min-ready=1"}); code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	for _, want := range []string{
+		ready.URL + "  ready  2.53.0",
+		down.URL + "  down   -",
+		"1 of 2 backends ready",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in health, got:\n%s", want, out)
+		}
+	}
+
+	out, _ = captureOutput(func() {
+		if code := RunCLI([]string{"health", backends}); code != 1 {
+			t.Errorf("expected exit code 1, got %d", code)
+		}
+	})
+	if !strings.Contains(out, "Only 1 backends ready, 2 required") {
+		t.Errorf("expected a failure message, got:\n%s", out)
+	}
+
+	out, _ = captureOutput(func() {
+		if code := RunCLI([]string{"health", backends, "--select=" + ready.URL}); code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	if strings.Contains(out, down.URL) || !strings.Contains(out, "1 of 1 backends ready") {
+		t.Errorf("expected only the selected backend, got:\n%s", out)
+	}
+}
+
+func TestRunCLI_HealthTimeout(t *testing.T) {
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hanging.Close()
+
+	started := time.Now()
+	out, _ := captureOutput(func() {
+		if code := RunCLI([]string{"health", "--backends=" + hanging.URL, "--timeout=100ms"}); code != 1 {
+			t.Errorf("expected exit code 1, got %d", code)
+		}
+	})
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("expected --timeout to bound the health check, took %s", elapsed)
+	}
+	if !strings.Contains(out, hanging.URL+"  down") {
+		t.Errorf("expected the hanging backend to be down, got:\n%s", out)
+	}
+}
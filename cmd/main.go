@@ -1,63 +1,353 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cortex-client/pkg/client"
 )
 
-// RunCLI runs the main CLI logic, returns exit code
+// RunCLI runs the main CLI logic, returns exit code. A leading argument that
+// is not a flag names a subcommand, otherwise a merged query is run
 func RunCLI(args []string) int {
-	return RunCLIWithMergeFunc(args, client.MergePrometheusQueries)
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return runSubcommand(args[0], args[1:])
+	}
+	return RunCLIWithMergeFunc(args, nil)
 }
 
-// RunCLIWithMergeFunc allows injecting a merge function for testing
+// RunCLIWithMergeFunc allows injecting a merge function for testing. When
+// mergeFunc is nil, queries go through a client configured from the flags
 func RunCLIWithMergeFunc(args []string, mergeFunc func(client.QueryData) ([]byte, error)) int {
 	flags := flag.NewFlagSet("cortex-client", flag.ContinueOnError)
 	backends := flags.String("backends", "", "Comma-separated list of Prometheus backend URLs")
-	backendsFile := flags.String("backends-file", "", "Path to file with Prometheus backend URLs (one per line)")
+	backendsFile := flags.String("backends-file", "", "Path to the YAML backends configuration file")
+	tenant := flags.String("tenant", "", "Tenant ID sent as X-Scope-OrgID to every backend, overriding the backends file; join tenants with | for federation")
+	selection := flags.String("select", "", "Comma-separated names of the backends or groups of the backends file to query, defaulting to all of them")
+	tenants := flags.String("tenants", "", "Comma-separated tenant IDs to query separately on every backend, labelling series with "+client.TenantLabel)
 	query := flags.String("query", "up", "Prometheus query string")
+	evalTime := flags.String("time", "", "Evaluation time of an instant query (RFC3339, unix timestamp or now-5m), defaults to now")
+	start := flags.String("start", "", "Start of a range query (RFC3339, unix timestamp or now-1h); enables range mode")
+	end := flags.String("end", "", "End of a range query (RFC3339, unix timestamp or now-5m), defaults to now")
+	step := flags.Duration("step", 15*time.Second, "Resolution step of a range query")
+	conflict := flags.String("conflict", string(client.ConflictFirstWins), "Policy for series returned by several backends: first, latest or keep-both")
+	backendLabel := flags.String("backend-label", client.DefaultBackendLabel, "Label distinguishing duplicate series with --conflict=keep-both")
+	dedup := flags.Bool("dedup", false, "Treat backends as HA replicas and deduplicate their series")
+	replicaLabel := flags.String("replica-label", client.DefaultReplicaLabel, "Label telling HA replicas apart, dropped by --dedup")
+	timeout := flags.Duration("timeout", 2*time.Minute, "Deadline for the whole merged query, 0 disables it")
+	backendTimeout := flags.Duration("backend-timeout", 30*time.Second, "Deadline for each backend request, forwarded as the Prometheus timeout parameter")
+	partialResponse := flags.String("partial-response", string(client.PartialResponseLenient), "What to do when some backends fail: lenient returns the rest with warnings, strict fails the query")
+	mode := flags.String("mode", string(client.QueryModeMerge), "How backends are used: merge queries all of them, hedge treats them as replicas and returns the first answer, hedging slow ones, failover tries them one at a time by descending weight, quorum requires --quorum of them to agree")
+	quorum := flags.Int("quorum", 0, "Number of backends that must return matching results with --mode=quorum, 0 means a majority")
+	tolerance := flags.String("tolerance", "", "How far values may differ for backends to agree with --mode=quorum: an absolute difference such as 0.5 or a relative one such as 1%")
+	healthCheck := flags.Bool("health-check", false, "Check the health of the backends first, skipping the ones that are down and flagging degraded ones")
+	method := flags.String("method", string(client.RequestMethodAuto), "HTTP method for API requests: auto switches from GET to POST for long queries, get or post")
+	explain := flags.Bool("explain", false, "Print which backends the query would be sent to and why, without running it")
 	if err := flags.Parse(args); err != nil {
 		fmt.Printf("Error parsing flags: %v\n", err)
 		return 2
 	}
 
-	var backendList []string
+	cfg, code := loadConfig(flags, *backends, *backendsFile, *tenant)
+	if code != 0 {
+		return code
+	}
 
-	if *backends != "" {
-		backendList = append(backendList, client.SplitAndTrim(*backends)...)
+	selected, code := selectBackends(cfg, *selection)
+	if code != 0 {
+		return code
 	}
 
-	if *backendsFile != "" {
-		fileBackends, err := client.ReadBackendFile(*backendsFile)
-		if err != nil {
-			fmt.Printf("Error reading backends file: %v\n", err)
-			return 1
+	tenantList := client.SplitAndTrim(*tenants)
+	for _, t := range tenantList {
+		if err := client.ValidateTenant(t); err != nil {
+			fmt.Printf("Invalid --tenants: %v\n", err)
+			return 2
 		}
-		backendList = append(backendList, fileBackends...)
 	}
 
-	if len(backendList) == 0 {
-		fmt.Println("Please provide at least one backend URL with --backends or --backends-file")
-		return 1
+	policy, err := client.ParseConflictPolicy(*conflict)
+	if err != nil {
+		fmt.Printf("Invalid --conflict: %v\n", err)
+		return 2
+	}
+
+	partial, err := client.ParsePartialResponseStrategy(*partialResponse)
+	if err != nil {
+		fmt.Printf("Invalid --partial-response: %v\n", err)
+		return 2
+	}
+
+	requestMethod, err := client.ParseRequestMethod(*method)
+	if err != nil {
+		fmt.Printf("Invalid --method: %v\n", err)
+		return 2
+	}
+
+	queryMode, err := client.ParseQueryMode(*mode)
+	if err != nil {
+		fmt.Printf("Invalid --mode: %v\n", err)
+		return 2
+	}
+
+	tol, err := client.ParseTolerance(*tolerance)
+	if err != nil {
+		fmt.Printf("Invalid --tolerance: %v\n", err)
+		return 2
+	}
+
+	// an injected merge function has no client configured from the flags, so
+	// it is handed the URLs of the selected backends and the tenant
+	backendRefs, backendTenant := selected, ""
+	if mergeFunc != nil {
+		backendRefs, backendTenant = resolveBackends(cfg, selected), *tenant
+	}
+
+	var c *client.Client
+	if mergeFunc == nil || *explain {
+		if c, err = client.New(client.WithConfig(cfg), client.WithRequestMethod(requestMethod)); err != nil {
+			fmt.Printf("Error creating client: %v\n", err)
+			return 1
+		}
+	}
+	if mergeFunc == nil {
+		mergeFunc = func(q client.QueryData) ([]byte, error) {
+			if *healthCheck {
+				c.CheckHealth(context.Background(), selected...)
+			}
+			return c.MergePrometheusQueries(context.Background(), q)
+		}
 	}
 
 	queryData := client.QueryData{
 		Query:    *query,
-		Backends: backendList,
+		Backends: backendRefs,
+		Tenant:   backendTenant,
+		Merge: client.MergeStrategy{
+			Conflict:     policy,
+			BackendLabel: *backendLabel,
+			Dedup:        *dedup,
+			ReplicaLabel: *replicaLabel,
+		},
+		PartialResponse: partial,
+		Timeout:         *timeout,
+		BackendTimeout:  *backendTimeout,
+		Tenants:         tenantList,
+		Mode:            queryMode,
+		Quorum:          *quorum,
+		Tolerance:       tol,
+	}
+
+	now := time.Now()
+	if *evalTime != "" {
+		if queryData.Time, err = parseTime(*evalTime, now); err != nil {
+			fmt.Printf("Invalid --time: %v\n", err)
+			return 2
+		}
+	}
+
+	if *start != "" {
+		startTime, err := parseTime(*start, now)
+		if err != nil {
+			fmt.Printf("Invalid --start: %v\n", err)
+			return 2
+		}
+		endTime := now
+		if *end != "" {
+			if endTime, err = parseTime(*end, now); err != nil {
+				fmt.Printf("Invalid --end: %v\n", err)
+				return 2
+			}
+		}
+		queryData.Start, queryData.End, queryData.Step = startTime, endTime, *step
+	}
+
+	if *explain {
+		decisions, err := c.Explain(queryData)
+		if err != nil {
+			fmt.Printf("Error explaining query: %v\n", err)
+			return 1
+		}
+		writeExplain(os.Stdout, decisions)
+		return 0
 	}
 
 	b, err := mergeFunc(queryData)
 	if err != nil {
 		fmt.Printf("Error merging queries: %v\n", err)
+		var quorumErr *client.QuorumError
+		if errors.As(err, &quorumErr) {
+			for _, d := range quorumErr.Report.Disagreements {
+				writeDifferences(os.Stdout, d.Backend, d.Reference, d.Differences)
+			}
+		}
 		return 1
 	}
 	fmt.Printf("Merged response:\n%s\n", string(b))
 	return 0
 }
 
+// writeDifferences lists how the result of backend differs from the one of
+// reference, after counting the differences of each kind
+func writeDifferences(out io.Writer, backend, reference string, diffs []client.Difference) {
+	counts := make(map[client.DiffKind]int)
+	for _, d := range diffs {
+		counts[d.Kind]++
+	}
+	var summary []string
+	for _, kind := range []client.DiffKind{client.DiffType, client.DiffMissing, client.DiffExtra, client.DiffValue, client.DiffGap} {
+		if counts[kind] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	fmt.Fprintf(out, "%s differs from %s (%s):\n", backend, reference, strings.Join(summary, ", "))
+	for _, d := range diffs {
+		fmt.Fprintf(out, "  %s\n", d)
+	}
+}
+
+// loadConfig builds the configuration of the backends given with --backends
+// and --backends-file, setting tenant on all of them when given. The global
+// settings of the file become the defaults of the timeout and partial response
+// flags that were not set explicitly. A non-zero code means there are no
+// backends or the flags were invalid and the reason was printed
+func loadConfig(flags *flag.FlagSet, backends, backendsFile, tenant string) (*client.Config, int) {
+	if tenant != "" {
+		if err := client.ValidateTenant(tenant); err != nil {
+			fmt.Printf("Invalid --tenant: %v\n", err)
+			return nil, 2
+		}
+	}
+
+	cfg := &client.Config{}
+	if backendsFile != "" {
+		var err error
+		if cfg, err = client.LoadConfig(backendsFile); err != nil {
+			fmt.Printf("Error reading backends file: %v\n", err)
+			return nil, 1
+		}
+	}
+
+	var backendList []client.BackendConfig
+	for _, u := range client.SplitAndTrim(backends) {
+		backendList = append(backendList, client.BackendConfig{URL: u})
+	}
+	cfg.Backends = append(backendList, cfg.Backends...)
+
+	if len(cfg.Backends) == 0 {
+		fmt.Println("Please provide at least one backend URL with --backends or --backends-file")
+		return nil, 1
+	}
+
+	if tenant != "" {
+		for i := range cfg.Backends {
+			cfg.Backends[i].Tenant = tenant
+		}
+	}
+
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	defaults := map[string]string{}
+	if cfg.Global.Timeout > 0 {
+		defaults["timeout"] = cfg.Global.Timeout.String()
+	}
+	if cfg.Global.BackendTimeout > 0 {
+		defaults["backend-timeout"] = cfg.Global.BackendTimeout.String()
+	}
+	if cfg.Global.PartialResponse != "" {
+		defaults["partial-response"] = string(cfg.Global.PartialResponse)
+	}
+	for name, value := range defaults {
+		if flags.Lookup(name) != nil && !set[name] {
+			if err := flags.Set(name, value); err != nil {
+				fmt.Printf("Invalid global %s in backends file: %v\n", name, err)
+				return nil, 1
+			}
+		}
+	}
+	return cfg, 0
+}
+
+// selectBackends resolves --select, a comma-separated list of backend and
+// group names of cfg. No selection means every backend
+func selectBackends(cfg *client.Config, selection string) ([]string, int) {
+	refs := client.SplitAndTrim(selection)
+	for _, ref := range refs {
+		_, isGroup := cfg.Groups[ref]
+		isBackend := slices.ContainsFunc(cfg.Backends, func(b client.BackendConfig) bool {
+			return b.Name == ref || b.URL == ref
+		})
+		if !isGroup && !isBackend {
+			fmt.Printf("Invalid --select: no backend or group named %q\n", ref)
+			return nil, 2
+		}
+	}
+	return refs, 0
+}
+
+// resolveBackends returns the URLs of the backends of cfg named by refs,
+// expanding groups, or of all of them when refs is empty
+func resolveBackends(cfg *client.Config, refs []string) []string {
+	if len(refs) == 0 {
+		for _, b := range cfg.Backends {
+			refs = append(refs, b.URL)
+		}
+		return refs
+	}
+	var urls []string
+	for _, ref := range refs {
+		members, ok := cfg.Groups[ref]
+		if !ok {
+			members = []string{ref}
+		}
+		for _, member := range members {
+			url := member
+			for _, b := range cfg.Backends {
+				if b.Name == member || b.URL == member {
+					url = b.URL
+					break
+				}
+			}
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// parseTime accepts an RFC3339 timestamp, unix seconds with optional fraction,
+// or a time relative to now such as now, now-5m or now+1h
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if rest, ok := strings.CutPrefix(s, "now"); ok {
+		if rest == "" {
+			return now, nil
+		}
+		offset, err := time.ParseDuration(rest)
+		if err != nil || (rest[0] != '-' && rest[0] != '+') {
+			return time.Time{}, fmt.Errorf("cannot parse %q as a relative time like now-5m", s)
+		}
+		return now.Add(offset), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	secs, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse %q as RFC3339, unix or relative timestamp", s)
+	}
+	return time.UnixMilli(int64(math.Round(secs * 1e3))), nil
+}
+
 func main() {
 	os.Exit(RunCLI(os.Args[1:]))
 }
@@ -4,9 +4,14 @@ import (
 	"bytes"
 	"io"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cortex-client/pkg/client"
 )
@@ -136,3 +141,340 @@ func TestRunCLI_InvalidFlag(t *testing.T) {
 		t.Errorf("expected flag parsing error, got: %s", out)
 	}
 }
+
+func TestRunCLI_RangeQuery(t *testing.T) {
+	var got client.QueryData
+	merge := func(q client.QueryData) ([]byte, error) {
+		got = q
+		return []byte("{\"status\":\"success\"}"), nil
+	}
+	_, _ = captureOutput(func() {
+		code := RunCLIWithMergeFunc([]string{"--backends=http://localhost:9090", "--start=2024-01-01T00:00:00Z", "--end=1704070800", "--step=1m"}, merge)
+		if code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	if !got.IsRange() {
+		t.Fatal("expected a range query")
+	}
+	if !got.Start.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || !got.End.Equal(time.Unix(1704070800, 0)) || got.Step != time.Minute {
+		t.Errorf("unexpected range: start=%s end=%s step=%s", got.Start, got.End, got.Step)
+	}
+}
+
+func TestRunCLI_InjectedMergeFuncBackends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backends.yaml")
+	content := "version: 1\n" +
+		"backends:\n" +
+		"  - {name: a, url: http://a:9090}\n" +
+		"  - {name: b, url: http://b:9090}\n" +
+		"  - {name: c, url: http://c:9090}\n" +
+		"groups:\n" +
+		"  ab: [a, b]\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write backends file: %v", err)
+	}
+
+	for _, tc := range []struct {
+		args   []string
+		want   []string
+		tenant string
+	}{
+		{[]string{"--backends=http://x:9090", "--backends-file=" + path}, []string{"http://x:9090", "http://a:9090", "http://b:9090", "http://c:9090"}, ""},
+		{[]string{"--backends-file=" + path, "--select=ab,c"}, []string{"http://a:9090", "http://b:9090", "http://c:9090"}, ""},
+		{[]string{"--backends-file=" + path, "--select=http://b:9090", "--tenant=team-a"}, []string{"http://b:9090"}, "team-a"},
+	} {
+		var got client.QueryData
+		merge := func(q client.QueryData) ([]byte, error) {
+			got = q
+			return []byte("{\"status\":\"success\"}"), nil
+		}
+		_, _ = captureOutput(func() {
+			if code := RunCLIWithMergeFunc(tc.args, merge); code != 0 {
+				t.Errorf("%v: expected exit code 0, got %d", tc.args, code)
+			}
+		})
+		if !reflect.DeepEqual(got.Backends, tc.want) {
+			t.Errorf("%v: expected backends %v, got %v", tc.args, tc.want, got.Backends)
+		}
+		if got.Tenant != tc.tenant {
+			t.Errorf("%v: expected tenant %q, got %q", tc.args, tc.tenant, got.Tenant)
+		}
+	}
+}
+
+func TestRunCLI_InvalidRangeStart(t *testing.T) {
+	out, _ := captureOutput(func() {
+		code := RunCLIWithMergeFunc([]string{"--backends=http://localhost:9090", "--start=yesterday"}, stubMergePrometheusQueries("", nil))
+		if code != 2 {
+			t.Errorf("expected exit code 2, got %d", code)
+		}
+	})
+	if !strings.Contains(out, "Invalid --start") {
+		t.Errorf("expected invalid start error, got: %s", out)
+	}
+}
+
+func TestRunCLI_QueryOptions(t *testing.T) {
+	var got client.QueryData
+	merge := func(q client.QueryData) ([]byte, error) {
+		got = q
+		return []byte("{\"status\":\"success\"}"), nil
+	}
+	_, _ = captureOutput(func() {
+		code := RunCLIWithMergeFunc([]string{"--backends=http://localhost:9090", "--conflict=keep-both", "--backend-label=src", "--dedup", "--replica-label=replica", "--partial-response=strict", "--timeout=10s", "--backend-timeout=2s", "--mode=hedge"}, merge)
+		if code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	if got.Merge.Conflict != client.ConflictKeepBoth || got.Merge.BackendLabel != "src" || !got.Merge.Dedup || got.Merge.ReplicaLabel != "replica" {
+		t.Errorf("unexpected merge strategy: %+v", got.Merge)
+	}
+	if got.PartialResponse != client.PartialResponseStrict {
+		t.Errorf("expected strict partial response, got %q", got.PartialResponse)
+	}
+	if got.Timeout != 10*time.Second || got.BackendTimeout != 2*time.Second {
+		t.Errorf("unexpected timeouts: %s, %s", got.Timeout, got.BackendTimeout)
+	}
+	if got.Mode != client.QueryModeHedge {
+		t.Errorf("expected hedge mode, got %q", got.Mode)
+	}
+
+	out, _ := captureOutput(func() {
+		code := RunCLIWithMergeFunc([]string{"--backends=http://localhost:9090", "--conflict=random"}, merge)
+		if code != 2 {
+			t.Errorf("expected exit code 2, got %d", code)
+		}
+	})
+	if !strings.Contains(out, "Invalid --conflict") {
+		t.Errorf("expected invalid conflict error, got: %s", out)
+	}
+
+	out, _ = captureOutput(func() {
+		code := RunCLIWithMergeFunc([]string{"--backends=http://localhost:9090", "--mode=fastest"}, merge)
+		if code != 2 {
+			t.Errorf("expected exit code 2, got %d", code)
+		}
+	})
+	if !strings.Contains(out, "Invalid --mode") {
+		t.Errorf("expected invalid mode error, got: %s", out)
+	}
+}
+
+func TestRunCLI_QueriesBackendWithClient(t *testing.T) {
+	methods := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods <- r.Method
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	out, _ := captureOutput(func() {
+		code := RunCLI([]string{"--backends=" + ts.URL, "--query=up", "--method=post"})
+		if code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	if method := <-methods; method != http.MethodPost {
+		t.Errorf("expected POST request, got %s", method)
+	}
+	if !strings.Contains(out, "Merged response") || !strings.Contains(out, `"resultType": "vector"`) {
+		t.Errorf("expected merged vector response, got: %s", out)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		input string
+		want  time.Time
+	}{
+		{"now", now},
+		{"now-5m", now.Add(-5 * time.Minute)},
+		{"now+1h30m", now.Add(90 * time.Minute)},
+		{"2024-01-01T10:00:00Z", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{"1704103200.5", time.UnixMilli(1704103200500)},
+	}
+	for _, c := range cases {
+		got, err := parseTime(c.input, now)
+		if err != nil {
+			t.Errorf("parseTime(%q) returned error: %v", c.input, err)
+			continue
+		}
+		if !got.Equal(c.want) {
+			t.Errorf("parseTime(%q) = %s, want %s", c.input, got, c.want)
+		}
+	}
+	for _, input := range []string{"now5m", "now-five", "yesterday"} {
+		if _, err := parseTime(input, now); err == nil {
+			t.Errorf("parseTime(%q) expected error, got nil", input)
+		}
+	}
+}
+
+func TestRunCLI_EvaluationTime(t *testing.T) {
+	var got client.QueryData
+	merge := func(q client.QueryData) ([]byte, error) {
+		got = q
+		return []byte("{\"status\":\"success\"}"), nil
+	}
+	_, _ = captureOutput(func() {
+		code := RunCLIWithMergeFunc([]string{"--backends=http://localhost:9090", "--time=1700000000"}, merge)
+		if code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	if !got.Time.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("expected pinned evaluation time, got %s", got.Time)
+	}
+
+	out, _ := captureOutput(func() {
+		code := RunCLIWithMergeFunc([]string{"--backends=http://localhost:9090", "--time=soon"}, merge)
+		if code != 2 {
+			t.Errorf("expected exit code 2, got %d", code)
+		}
+	})
+	if !strings.Contains(out, "Invalid --time") {
+		t.Errorf("expected invalid time error, got: %s", out)
+	}
+}
+
+func TestRunCLI_TenantOverride(t *testing.T) {
+	tenants := make(chan string, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenants <- r.Header.Get(client.TenantHeader)
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"api"},"value":[1700000000,"1"]}]}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	out, _ := captureOutput(func() {
+		if code := RunCLI([]string{"--backends=" + ts.URL, "--tenant=team-a|team-b"}); code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	if got := <-tenants; got != "team-a|team-b" {
+		t.Errorf("expected federated tenant header, got %q", got)
+	}
+
+	out, _ = captureOutput(func() {
+		if code := RunCLI([]string{"--backends=" + ts.URL, "--tenants=team-a,team-b"}); code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	<-tenants
+	<-tenants
+	if !strings.Contains(out, `"__tenant_id__": "team-a"`) || !strings.Contains(out, `"__tenant_id__": "team-b"`) {
+		t.Errorf("expected series labelled by tenant, got: %s", out)
+	}
+
+	out, _ = captureOutput(func() {
+		if code := RunCLI([]string{"--backends=" + ts.URL, "--tenant=team/a"}); code != 2 {
+			t.Errorf("expected exit code 2, got %d", code)
+		}
+	})
+	if !strings.Contains(out, "Invalid --tenant") {
+		t.Errorf("expected invalid tenant message, got: %s", out)
+	}
+}
+
+func TestRunCLI_ConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backends.yaml")
+	content := "version: 1\n" +
+		"global: {timeout: 45s, backend_timeout: 5s, partial_response: strict}\n" +
+		"backends:\n" +
+		"  - {name: a, url: http://a:9090}\n" +
+		"  - {name: b, url: http://b:9090}\n" +
+		"groups:\n" +
+		"  pair: [a, b]\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write backends file: %v", err)
+	}
+
+	var got client.QueryData
+	merge := func(q client.QueryData) ([]byte, error) {
+		got = q
+		return []byte("{\"status\":\"success\"}"), nil
+	}
+	_, _ = captureOutput(func() {
+		if code := RunCLIWithMergeFunc([]string{"--backends-file=" + path, "--select=pair", "--backend-timeout=1s"}, merge); code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	if !reflect.DeepEqual(got.Backends, []string{"http://a:9090", "http://b:9090"}) {
+		t.Errorf("expected the members of the selected group, got %v", got.Backends)
+	}
+	if got.Timeout != 45*time.Second || got.BackendTimeout != time.Second || got.PartialResponse != client.PartialResponseStrict {
+		t.Errorf("expected the file's globals unless overridden, got %s, %s, %s", got.Timeout, got.BackendTimeout, got.PartialResponse)
+	}
+
+	out, _ := captureOutput(func() {
+		if code := RunCLIWithMergeFunc([]string{"--backends-file=" + path, "--select=c"}, merge); code != 2 {
+			t.Errorf("expected exit code 2, got %d", code)
+		}
+	})
+	if !strings.Contains(out, "Invalid --select") {
+		t.Errorf("expected invalid selection message, got: %s", out)
+	}
+
+	if err := os.WriteFile(path, []byte("version: 1\nbackends:\n  - {name: a, url: http://a, wieght: 1}\n"), 0o600); err != nil {
+		t.Fatalf("failed to write backends file: %v", err)
+	}
+	out, _ = captureOutput(func() {
+		if code := RunCLIWithMergeFunc([]string{"--backends-file=" + path}, merge); code != 1 {
+			t.Errorf("expected exit code 1, got %d", code)
+		}
+	})
+	if !strings.Contains(out, "line 3: field wieght not found") {
+		t.Errorf("expected a line-numbered error, got: %s", out)
+	}
+}
+
+func TestRunCLI_Quorum(t *testing.T) {
+	server := func(value string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"node"},"value":[1700000000,"` + value + `"]}]}}`)); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		}))
+	}
+	a, b := server("10"), server("10.5")
+	defer a.Close()
+	defer b.Close()
+	backends := "--backends=" + a.URL + "," + b.URL
+
+	out, _ := captureOutput(func() {
+		if code := RunCLI([]string{backends, "--mode=quorum", "--quorum=2", "--tolerance=10%"}); code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	if !strings.Contains(out, `"answeredBy": "`+a.URL+`"`) || !strings.Contains(out, `"required": 2`) {
+		t.Errorf("expected a quorum report, got: %s", out)
+	}
+
+	out, _ = captureOutput(func() {
+		if code := RunCLI([]string{backends, "--mode=quorum", "--quorum=2"}); code != 1 {
+			t.Errorf("expected exit code 1, got %d", code)
+		}
+	})
+	for _, want := range []string{"quorum not reached: 1 backends agree, 2 required", b.URL + " differs from " + a.URL, "is 10.5, expected 10"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the output, got: %s", want, out)
+		}
+	}
+
+	out, _ = captureOutput(func() {
+		if code := RunCLI([]string{backends, "--mode=quorum", "--tolerance=lots"}); code != 2 {
+			t.Errorf("expected exit code 2, got %d", code)
+		}
+	})
+	if !strings.Contains(out, "Invalid --tolerance") {
+		t.Errorf("expected an invalid tolerance error, got: %s", out)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cortex-client/pkg/client"
+)
+
+// stringList is a flag that may be repeated, collecting every value
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// metadataFlags are the flags shared by the subcommands talking to the
+// metadata and status endpoints
+type metadataFlags struct {
+	backends        string
+	backendsFile    string
+	tenant          string
+	selection       string
+	timeout         time.Duration
+	backendTimeout  time.Duration
+	partialResponse string
+	method          string
+
+	// flags is the set the flags were registered on
+	flags *flag.FlagSet
+}
+
+func (f *metadataFlags) register(flags *flag.FlagSet) {
+	f.flags = flags
+	flags.StringVar(&f.backends, "backends", "", "Comma-separated list of Prometheus backend URLs")
+	flags.StringVar(&f.backendsFile, "backends-file", "", "Path to the YAML backends configuration file")
+	flags.StringVar(&f.selection, "select", "", "Comma-separated names of the backends or groups of the backends file to query, defaulting to all of them")
+	flags.StringVar(&f.tenant, "tenant", "", "Tenant ID sent as X-Scope-OrgID to every backend, overriding the backends file; join tenants with | for federation")
+	flags.DurationVar(&f.timeout, "timeout", 2*time.Minute, "Deadline for the whole request, 0 disables it")
+	flags.DurationVar(&f.backendTimeout, "backend-timeout", 30*time.Second, "Deadline for each backend request")
+	flags.StringVar(&f.partialResponse, "partial-response", string(client.PartialResponseLenient), "What to do when some backends fail: lenient returns the rest with warnings, strict fails the request")
+	flags.StringVar(&f.method, "method", string(client.RequestMethodAuto), "HTTP method for API requests: auto switches from GET to POST for long queries, get or post")
+}
+
+// client builds the client and base query described by the flags, applying
+// opts after the configuration. A non-zero code means the flags were invalid
+// and the reason was printed
+func (f *metadataFlags) client(opts ...client.Option) (*client.Client, client.MetadataQuery, int) {
+	cfg, code := loadConfig(f.flags, f.backends, f.backendsFile, f.tenant)
+	if code != 0 {
+		return nil, client.MetadataQuery{}, code
+	}
+
+	selected, code := selectBackends(cfg, f.selection)
+	if code != 0 {
+		return nil, client.MetadataQuery{}, code
+	}
+
+	partial, err := client.ParsePartialResponseStrategy(f.partialResponse)
+	if err != nil {
+		fmt.Printf("Invalid --partial-response: %v\n", err)
+		return nil, client.MetadataQuery{}, 2
+	}
+
+	requestMethod, err := client.ParseRequestMethod(f.method)
+	if err != nil {
+		fmt.Printf("Invalid --method: %v\n", err)
+		return nil, client.MetadataQuery{}, 2
+	}
+
+	opts = append([]client.Option{client.WithConfig(cfg), client.WithRequestMethod(requestMethod)}, opts...)
+	c, err := client.New(opts...)
+	if err != nil {
+		fmt.Printf("Error creating client: %v\n", err)
+		return nil, client.MetadataQuery{}, 1
+	}
+
+	q := client.MetadataQuery{
+		Backends:        selected,
+		PartialResponse: partial,
+		Timeout:         f.timeout,
+		BackendTimeout:  f.backendTimeout,
+	}
+	return c, q, 0
+}
+
+// runSubcommand runs the named subcommand with its own flags, returns exit code
+func runSubcommand(name string, args []string) int {
+	switch name {
+	case "series", "labels", "label-values":
+		return runMetadata(name, args)
+	case "report":
+		return runReport(args)
+	case "exemplars":
+		return runExemplars(args)
+	case "status":
+		return runStatus(args)
+	case "health":
+		return runHealth(args)
+	case "diff":
+		return runDiff(args)
+	}
+	fmt.Printf("Unknown command %q, expected series, labels, label-values, report, exemplars, status, health or diff\n", name)
+	return 2
+}
+
+// runMetadata lists series, label names or the values of a label across the
+// backends and prints the merged JSON response
+func runMetadata(command string, args []string) int {
+	// the label name of label-values may come before its flags
+	var labelName string
+	if command == "label-values" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		labelName, args = args[0], args[1:]
+	}
+
+	flags := flag.NewFlagSet("cortex-client "+command, flag.ContinueOnError)
+	var common metadataFlags
+	common.register(flags)
+	var matchers stringList
+	flags.Var(&matchers, "match", "Series selector such as up{job=\"node\"}, may be repeated")
+	start := flags.String("start", "", "Start of the time range searched (RFC3339, unix timestamp or now-1h)")
+	end := flags.String("end", "", "End of the time range searched (RFC3339, unix timestamp or now-5m)")
+	if err := flags.Parse(args); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		return 2
+	}
+
+	if command == "label-values" && labelName == "" {
+		labelName = flags.Arg(0)
+	}
+	if command == "label-values" && labelName == "" {
+		fmt.Println("Please provide the label name, e.g. label-values job")
+		return 2
+	}
+	if command == "series" && len(matchers) == 0 {
+		fmt.Println("Please provide at least one series selector with --match")
+		return 2
+	}
+
+	c, q, code := common.client()
+	if code != 0 {
+		return code
+	}
+	q.Matchers = matchers
+
+	var err error
+	now := time.Now()
+	if *start != "" {
+		if q.Start, err = parseTime(*start, now); err != nil {
+			fmt.Printf("Invalid --start: %v\n", err)
+			return 2
+		}
+	}
+	if *end != "" {
+		if q.End, err = parseTime(*end, now); err != nil {
+			fmt.Printf("Invalid --end: %v\n", err)
+			return 2
+		}
+	}
+
+	ctx := context.Background()
+	var resp any
+	switch command {
+	case "series":
+		resp, err = c.Series(ctx, q)
+	case "labels":
+		resp, err = c.LabelNames(ctx, q)
+	case "label-values":
+		resp, err = c.LabelValues(ctx, labelName, q)
+	}
+	if err != nil {
+		fmt.Printf("Error listing %s: %v\n", command, err)
+		return 1
+	}
+
+	b, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		fmt.Printf("Error encoding response: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(b))
+	return 0
+}
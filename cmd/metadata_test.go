@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRunCLI_Series(t *testing.T) {
+	forms := make(chan url.Values, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		forms <- r.Form
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":[{"__name__":"up","job":"node"}]}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	out, _ := captureOutput(func() {
+		code := RunCLI([]string{"series", "--backends=" + ts.URL, "--match=up", "--match=process_start_time_seconds", "--start=1700000000", "--end=1700003600"})
+		if code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	form := <-forms
+	if got := strings.Join(form["match[]"], ","); got != "up,process_start_time_seconds" {
+		t.Errorf("expected both matchers to be sent, got %s", got)
+	}
+	if form.Get("start") != "1700000000" || form.Get("end") != "1700003600" {
+		t.Errorf("expected time range to be sent, got %v", form)
+	}
+	if !strings.Contains(out, `"job": "node"`) {
+		t.Errorf("expected series in output, got: %s", out)
+	}
+}
+
+func TestRunCLI_LabelValues(t *testing.T) {
+	paths := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":["api","node"]}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	out, _ := captureOutput(func() {
+		code := RunCLI([]string{"label-values", "job", "--backends=" + ts.URL})
+		if code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	if path := <-paths; path != "/api/v1/label/job/values" {
+		t.Errorf("unexpected request path %s", path)
+	}
+	if !strings.Contains(out, `"api"`) || !strings.Contains(out, `"node"`) {
+		t.Errorf("expected label values in output, got: %s", out)
+	}
+}
+
+func TestRunCLI_MetadataUsageErrors(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"series", "--backends=http://localhost:9090"}, "--match"},
+		{[]string{"label-values", "--backends=http://localhost:9090"}, "label name"},
+		{[]string{"bogus"}, "Unknown command"},
+	}
+	for _, tc := range cases {
+		out, _ := captureOutput(func() {
+			if code := RunCLI(tc.args); code != 2 {
+				t.Errorf("%v: expected exit code 2, got %d", tc.args, code)
+			}
+		})
+		if !strings.Contains(out, tc.want) {
+			t.Errorf("%v: expected %q in output, got: %s", tc.args, tc.want, out)
+		}
+	}
+}
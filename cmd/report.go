@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cortex-client/pkg/client"
+)
+
+// runReport prints a fleet-wide health report: unreachable backends, down
+// targets, failing rules and firing alerts, each row naming its backend
+func runReport(args []string) int {
+	flags := flag.NewFlagSet("cortex-client report", flag.ContinueOnError)
+	var common metadataFlags
+	common.register(flags)
+	if err := flags.Parse(args); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		return 2
+	}
+
+	c, q, code := common.client()
+	if code != 0 {
+		return code
+	}
+
+	ctx := context.Background()
+	targets, err := c.Targets(ctx, "active", q)
+	if err != nil {
+		fmt.Printf("Error listing targets: %v\n", err)
+		return 1
+	}
+	rules, err := c.Rules(ctx, "", q)
+	if err != nil {
+		fmt.Printf("Error listing rules: %v\n", err)
+		return 1
+	}
+	alerts, err := c.Alerts(ctx, q)
+	if err != nil {
+		fmt.Printf("Error listing alerts: %v\n", err)
+		return 1
+	}
+
+	writeReport(os.Stdout, targets, rules, alerts)
+	return 0
+}
+
+// writeReport renders the report as one table per section
+func writeReport(out io.Writer, targets *client.TargetsResponse, rules *client.RulesResponse, alerts *client.AlertsResponse) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	// a backend failing any of the requests is reported once, with its first error
+	var failed []client.BackendStatus
+	seen := make(map[string]bool)
+	for _, statuses := range [][]client.BackendStatus{targets.Backends, rules.Backends, alerts.Backends} {
+		for _, s := range statuses {
+			if s.Status != client.BackendStatusSuccess && !seen[s.Backend] {
+				seen[s.Backend] = true
+				failed = append(failed, s)
+			}
+		}
+	}
+	fmt.Fprintf(w, "Unreachable backends: %d\n", len(failed))
+	if len(failed) > 0 {
+		fmt.Fprintln(w, "BACKEND\tERROR")
+		for _, s := range failed {
+			fmt.Fprintf(w, "%s\t%s\n", s.Backend, s.Error)
+		}
+	}
+
+	var down []client.Target
+	for _, t := range targets.Data.ActiveTargets {
+		if t.Health == client.TargetHealthDown {
+			down = append(down, t)
+		}
+	}
+	fmt.Fprintf(w, "\nDown targets: %d of %d\n", len(down), len(targets.Data.ActiveTargets))
+	if len(down) > 0 {
+		fmt.Fprintln(w, "BACKEND\tPOOL\tINSTANCE\tLAST SCRAPE\tERROR")
+		for _, t := range down {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.Backend, t.ScrapePool, t.Labels["instance"], formatReportTime(t.LastScrape), t.LastError)
+		}
+	}
+
+	var failingGroups, failingRules int
+	for _, g := range rules.Data.Groups {
+		if n := len(g.FailingRules()); n > 0 {
+			failingGroups++
+			failingRules += n
+		}
+	}
+	fmt.Fprintf(w, "\nFailing rule groups: %d of %d (%d rules)\n", failingGroups, len(rules.Data.Groups), failingRules)
+	if failingGroups > 0 {
+		fmt.Fprintln(w, "BACKEND\tGROUP\tFILE\tRULE\tERROR")
+		for _, g := range rules.Data.Groups {
+			for _, r := range g.FailingRules() {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", g.Backend, g.Name, g.File, r.Name, r.LastError)
+			}
+		}
+	}
+
+	var firing []client.Alert
+	for _, a := range alerts.Data.Alerts {
+		if a.State == "firing" {
+			firing = append(firing, a)
+		}
+	}
+	fmt.Fprintf(w, "\nFiring alerts: %d\n", len(firing))
+	if len(firing) > 0 {
+		fmt.Fprintln(w, "BACKEND\tALERT\tSINCE\tLABELS")
+		for _, a := range firing {
+			var since string
+			if a.ActiveAt != nil {
+				since = formatReportTime(*a.ActiveAt)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.Backend, a.Labels["alertname"], since, a.Labels)
+		}
+	}
+}
+
+func formatReportTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
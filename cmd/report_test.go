@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunCLI_Report(t *testing.T) {
+	bodies := map[string]string{
+		"/api/v1/targets": `{"status":"success","data":{"activeTargets":[{"scrapePool":"node","labels":{"instance":"a:9100"},"health":"down","lastError":"connection refused"},{"scrapePool":"node","labels":{"instance":"b:9100"},"health":"up"}],"droppedTargets":[]}}`,
+		"/api/v1/rules":   `{"status":"success","data":{"groups":[{"name":"node","file":"node.yml","rules":[{"name":"NodeDown","type":"alerting","health":"err","lastError":"bad query"},{"name":"job:up:sum","type":"recording","health":"ok"}]}]}}`,
+		"/api/v1/alerts":  `{"status":"success","data":{"alerts":[{"labels":{"alertname":"InstanceDown","instance":"a:9100"},"state":"firing","activeAt":"2024-01-01T00:00:00Z","value":"1e+00"}]}}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(bodies[r.URL.Path])); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	out, _ := captureOutput(func() {
+		code := RunCLI([]string{"report", "--backends=" + ts.URL + ",http://invalid:9999"})
+		if code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	for _, want := range []string{
+		"Unreachable backends: 1",
+		"http://invalid:9999",
+		"Down targets: 1 of 2",
+		ts.URL + "  node  a:9100",
+		"Failing rule groups: 1 of 1 (1 rules)",
+		"node.yml  NodeDown  bad query",
+		"Firing alerts: 1",
+		"InstanceDown  2024-01-01T00:00:00Z",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in report, got:\n%s", want, out)
+		}
+	}
+}
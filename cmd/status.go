@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/cortex-client/pkg/client"
+)
+
+// runStatus probes the backends with a cheap query and prints the state of
+// their circuit breakers. Breakers only live as long as the process, so the
+// state shown comes from these probes alone and not from earlier queries
+func runStatus(args []string) int {
+	flags := flag.NewFlagSet("cortex-client status", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", flags.Name())
+		fmt.Fprintln(flags.Output(), "Sends --probes rounds of live queries to every backend, without retries, and prints the circuit breaker")
+		fmt.Fprintln(flags.Output(), "state they leave behind. Breakers are not shared between runs, so the state reflects these probes only,")
+		fmt.Fprintln(flags.Output(), "not the history of earlier queries, and every run adds load to the backends.")
+		flags.PrintDefaults()
+	}
+	var common metadataFlags
+	common.register(flags)
+	query := flags.String("query", "vector(1)", "Query sent to every backend to probe it")
+	probes := flags.Int("probes", 1, "Number of probe rounds; a breaker opens after as many consecutive failures as its failure threshold")
+	if err := flags.Parse(args); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		return 2
+	}
+	if *probes < 1 {
+		fmt.Println("Invalid --probes: must be at least 1")
+		return 2
+	}
+
+	// every probe counts on its own, retrying would only add load
+	c, q, code := common.client(client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 1}))
+	if code != 0 {
+		return code
+	}
+
+	var last []client.BackendStatus
+	for range *probes {
+		// failed probes are what the breakers are fed with, so errors are expected
+		merged, err := c.Query(context.Background(), client.QueryData{
+			Query:           *query,
+			Backends:        q.Backends,
+			PartialResponse: client.PartialResponseLenient,
+			Timeout:         q.Timeout,
+			BackendTimeout:  q.BackendTimeout,
+		})
+		var partialErr *client.PartialResponseError
+		switch {
+		case err == nil:
+			last = merged.Backends
+		case errors.As(err, &partialErr):
+			last = partialErr.Backends
+		default:
+			fmt.Printf("Error probing backends: %v\n", err)
+			return 1
+		}
+	}
+
+	writeStatus(os.Stdout, c, last)
+	return 0
+}
+
+// writeStatus renders the breaker state of every probed backend along with
+// the outcome of its last probe
+func writeStatus(out io.Writer, c *client.Client, probed []client.BackendStatus) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	urls := make(map[string]string)
+	for _, b := range c.Backends() {
+		urls[b.String()] = b.URL
+	}
+	fmt.Fprintln(w, "BACKEND\tSTATE\tFAILURES\tOPENED\tLAST PROBE\tLAST ERROR")
+	for _, s := range probed {
+		url, ok := urls[s.Backend]
+		if !ok {
+			url = s.Backend
+		}
+		breaker := c.Breaker(url)
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", s.Backend, breaker.State, breaker.Failures, formatStatusTime(breaker), s.Status, breaker.LastError)
+	}
+}
+
+func formatStatusTime(b client.BreakerStatus) string {
+	if b.OpenedAt.IsZero() {
+		return "-"
+	}
+	return formatReportTime(b.OpenedAt)
+}
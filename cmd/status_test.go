@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunCLI_Status(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1700000000,"1"]}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	path := filepath.Join(t.TempDir(), "backends.yaml")
+	content := "version: 1\n" +
+		"global:\n" +
+		"  retry: {max_attempts: 1}\n" +
+		"  circuit_breaker: {failure_threshold: 2, open_timeout: 1h}\n" +
+		"backends:\n" +
+		"  - {name: up, url: " + up.URL + "}\n" +
+		"  - {name: down, url: " + down.URL + "}\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write backends file: %v", err)
+	}
+
+	out, _ := captureOutput(func() {
+		if code := RunCLI([]string{"status", "--backends-file=" + path, "--probes=3"}); code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	for _, want := range []string{
+		"BACKEND  STATE",
+		"up       closed  0",
+		"down     open    2",
+		"skipped     HTTP 502: Bad Gateway",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in status, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunCLI_StatusDefaults(t *testing.T) {
+	var calls atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	out, _ := captureOutput(func() {
+		if code := RunCLI([]string{"status", "--backends=" + down.URL}); code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected a single probe without retries, got %d requests", got)
+	}
+	if !strings.Contains(out, "closed  1") {
+		t.Errorf("expected one failure on a closed breaker, got:\n%s", out)
+	}
+
+	_, help := captureOutput(func() {
+		if code := RunCLI([]string{"status", "-h"}); code != 2 {
+			t.Errorf("expected exit code 2, got %d", code)
+		}
+	})
+	if !strings.Contains(help, "reflects these probes only") {
+		t.Errorf("expected the help to describe the probes, got:\n%s", help)
+	}
+	_, _ = captureOutput(func() {
+		if code := RunCLI([]string{"status", "--backends=" + down.URL, "--probes=0"}); code != 2 {
+			t.Errorf("expected exit code 2, got %d", code)
+		}
+	})
+}
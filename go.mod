@@ -5,12 +5,10 @@ go 1.24.3
 require (
 	github.com/docker/go-connections v0.5.0
 	github.com/testcontainers/testcontainers-go v0.37.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	github.com/testcontainers/testcontainers-go/modules/grafana-lgtm v0.37.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
-)
+require github.com/testcontainers/testcontainers-go/modules/grafana-lgtm v0.37.0 // indirect
 
 require (
 	dario.cat/mergo v1.0.1 // indirect
@@ -65,5 +63,4 @@ require (
 	golang.org/x/sys v0.33.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250603155806-513f23925822 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
-	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Authenticator adds credentials to the requests sent to a backend. It is
+// called with a copy of every request and must be safe for concurrent use
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// BasicAuth holds the HTTP basic auth credentials of a backend
+type BasicAuth struct {
+	Username string `yaml:"username"`
+
+	// Password is used as is, PasswordFile is read on every request so that
+	// rotated credentials are picked up
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"password_file"`
+}
+
+// BearerToken is the token sent in the Authorization header of a backend.
+// Exactly one source is set: the token itself, a file read on every request
+// or an environment variable looked up on every request
+type BearerToken struct {
+	Token string
+	File  string
+	Env   string
+}
+
+// TLSConfig configures the TLS connection to a backend
+type TLSConfig struct {
+	// CAFile is a PEM bundle of the CAs trusted to sign the server certificate,
+	// defaulting to the system roots
+	CAFile string `yaml:"ca_file"`
+
+	// CertFile and KeyFile are the PEM client certificate and key for mTLS
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+
+	// ServerName overrides the name the server certificate is verified against
+	ServerName string `yaml:"server_name"`
+
+	// InsecureSkipVerify disables verification of the server certificate
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+func (a *BasicAuth) validate() error {
+	if a.Username == "" {
+		return errors.New("basic auth needs a username")
+	}
+	if a.Password != "" && a.PasswordFile != "" {
+		return errors.New("basic auth password and password_file are mutually exclusive")
+	}
+	return nil
+}
+
+// Authenticate sets the basic auth header, reading the password file if any
+func (a *BasicAuth) Authenticate(req *http.Request) error {
+	password := a.Password
+	if a.PasswordFile != "" {
+		var err error
+		if password, err = readSecretFile(a.PasswordFile); err != nil {
+			return fmt.Errorf("reading basic auth password: %w", err)
+		}
+	}
+	req.SetBasicAuth(a.Username, password)
+	return nil
+}
+
+func (t *BearerToken) validate() error {
+	sources := 0
+	for _, s := range []string{t.Token, t.File, t.Env} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return errors.New("bearer token needs exactly one of a token, a file or an environment variable")
+	}
+	return nil
+}
+
+// Authenticate sets the Authorization header from the token's source
+func (t *BearerToken) Authenticate(req *http.Request) error {
+	token := t.Token
+	switch {
+	case t.File != "":
+		var err error
+		if token, err = readSecretFile(t.File); err != nil {
+			return fmt.Errorf("reading bearer token: %w", err)
+		}
+	case t.Env != "":
+		if token = os.Getenv(t.Env); token == "" {
+			return fmt.Errorf("environment variable %s holding the bearer token is empty", t.Env)
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (c *TLSConfig) validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("TLS cert_file and key_file must be set together")
+	}
+	return nil
+}
+
+// config loads the certificates into a tls.Config
+func (c *TLSConfig) config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", c.CAFile)
+		}
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// readSecretFile reads a credential from path, dropping trailing newlines
+func readSecretFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// authenticator returns the authenticator selected by the backend, if any.
+// OAuth2 tokens are fetched with tokenClient
+func (b Backend) authenticator(tokenClient *http.Client) Authenticator {
+	switch {
+	case b.Authenticator != nil:
+		return b.Authenticator
+	case b.BasicAuth != nil:
+		return b.BasicAuth
+	case b.BearerToken != nil:
+		return b.BearerToken
+	case b.OAuth2 != nil:
+		return newOAuth2TokenSource(b.OAuth2, tokenClient)
+	case b.SigV4 != nil:
+		return b.SigV4
+	}
+	return nil
+}
+
+// authenticators counts the authentication methods set on the backend
+func (b Backend) authenticators() int {
+	n := 0
+	for _, set := range []bool{b.Authenticator != nil, b.BasicAuth != nil, b.BearerToken != nil, b.OAuth2 != nil, b.SigV4 != nil} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// newBackendHTTPClient builds the HTTP client of a backend with its own
+// transport, derived from base, when the backend needs credentials or TLS
+// settings. It returns nil for backends that can share base
+func newBackendHTTPClient(base *http.Client, b Backend) (*http.Client, error) {
+	if b.authenticators() == 0 && b.TLS == nil {
+		return nil, nil
+	}
+	client := *base
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if b.TLS != nil {
+		t, ok := transport.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("TLS settings need an *http.Transport to extend, got %T", transport)
+		}
+		tlsConfig, err := b.TLS.config()
+		if err != nil {
+			return nil, err
+		}
+		t = t.Clone()
+		t.TLSClientConfig = tlsConfig
+		transport = t
+	}
+	// tokens are fetched over the backend's TLS settings but without its credentials
+	tokenClient := &http.Client{Transport: transport, Timeout: client.Timeout}
+	if auth := b.authenticator(tokenClient); auth != nil {
+		transport = &authTransport{next: transport, auth: auth}
+	}
+	client.Transport = transport
+	return &client, nil
+}
+
+// authTransport adds a backend's credentials to every request
+type authTransport struct {
+	next http.RoundTripper
+	auth Authenticator
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the caller's request
+	authed := req.Clone(req.Context())
+	if err := t.auth.Authenticate(authed); err != nil {
+		// a RoundTripper must close the body even when it fails
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(authed)
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// authHandler answers with an empty vector and reports the Authorization header
+func authHandler(t *testing.T, auth chan<- string) http.HandlerFunc {
+	t.Helper()
+	return func(w http.ResponseWriter, r *http.Request) {
+		auth <- r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestClient_BackendCredentials(t *testing.T) {
+	auth := make(chan string, 1)
+	ts := httptest.NewServer(authHandler(t, auth))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	t.Setenv("CORTEX_CLIENT_TEST_TOKEN", "from-env")
+	cases := []struct {
+		name    string
+		backend Backend
+		want    string
+	}{
+		{"basic", Backend{BasicAuth: &BasicAuth{Username: "user", Password: "pass"}}, "Basic dXNlcjpwYXNz"},
+		{"basic file", Backend{BasicAuth: &BasicAuth{Username: "user", PasswordFile: writeFile(t, dir, "password", "pass\n")}}, "Basic dXNlcjpwYXNz"},
+		{"bearer", Backend{BearerToken: &BearerToken{Token: "inline"}}, "Bearer inline"},
+		{"bearer file", Backend{BearerToken: &BearerToken{File: writeFile(t, dir, "token", "from-file\n")}}, "Bearer from-file"},
+		{"bearer env", Backend{BearerToken: &BearerToken{Env: "CORTEX_CLIENT_TEST_TOKEN"}}, "Bearer from-env"},
+		{"none", Backend{}, ""},
+	}
+	for _, tc := range cases {
+		tc.backend.Name, tc.backend.URL = tc.name, ts.URL
+		c, err := New(WithBackends(tc.backend))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if _, err := c.QueryPrometheus(context.Background(), tc.name, "up"); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if got := <-auth; got != tc.want {
+			t.Errorf("%s: got Authorization %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestClient_InvalidCredentials(t *testing.T) {
+	for _, b := range []Backend{
+		{URL: "http://localhost", BasicAuth: &BasicAuth{Password: "pass"}},
+		{URL: "http://localhost", BearerToken: &BearerToken{Token: "a", Env: "B"}},
+		{URL: "http://localhost", BasicAuth: &BasicAuth{Username: "u"}, BearerToken: &BearerToken{Token: "a"}},
+		{URL: "http://localhost", TLS: &TLSConfig{CertFile: "cert.pem"}},
+		{URL: "http://localhost", TLS: &TLSConfig{CAFile: "missing.pem"}},
+	} {
+		if _, err := New(WithBackends(b)); err == nil {
+			t.Errorf("%+v: expected error, got nil", b)
+		}
+	}
+}
+
+// writeCert creates a self-signed certificate and key and returns their paths
+func writeCert(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	certPath := writeFile(t, dir, name+".crt", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	keyPath := writeFile(t, dir, name+".key", string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})))
+	return certPath, keyPath
+}
+
+func TestClient_BackendTLS(t *testing.T) {
+	clientCerts := make(chan int, 1)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientCerts <- len(r.TLS.PeerCertificates)
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	dir := t.TempDir()
+	caFile := writeFile(t, dir, "ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})))
+	certFile, keyFile := writeCert(t, dir, "client")
+
+	cases := []struct {
+		name  string
+		tls   *TLSConfig
+		certs int
+		fails bool
+	}{
+		{"untrusted", nil, 0, true},
+		{"ca", &TLSConfig{CAFile: caFile}, 0, false},
+		{"insecure", &TLSConfig{InsecureSkipVerify: true}, 0, false},
+		{"wrong server name", &TLSConfig{CAFile: caFile, ServerName: "prometheus.invalid"}, 0, true},
+		{"mtls", &TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}, 1, false},
+	}
+	for _, tc := range cases {
+		c, err := New(WithBackends(Backend{Name: tc.name, URL: ts.URL, TLS: tc.tls}))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		_, err = c.QueryPrometheus(context.Background(), tc.name, "up")
+		if tc.fails {
+			if err == nil {
+				t.Errorf("%s: expected TLS error, got nil", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if got := <-clientCerts; got != tc.certs {
+			t.Errorf("%s: server saw %d client certificates, want %d", tc.name, got, tc.certs)
+		}
+	}
+}
+
+func TestReadBackends_Credentials(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "backends.yaml", "prometheus_backends:\n"+
+		"  - url: https://prometheus:9090\n"+
+		"    basic_auth:\n"+
+		"      username: user\n"+
+		"      password_file: secrets/password\n"+
+		"    tls_config:\n"+
+		"      ca_file: /etc/ca.pem\n"+
+		"      insecure_skip_verify: true\n"+
+		"  - url: https://cortex\n"+
+		"    bearer_token_env: CORTEX_TOKEN\n"+
+		"  - url: https://managed\n"+
+		"    oauth2:\n"+
+		"      client_id: client\n"+
+		"      client_secret_file: secrets/client-secret\n"+
+		"      token_url: https://auth/token\n"+
+		"      scopes: [read]\n"+
+		"  - url: https://aps-workspaces.eu-west-1.amazonaws.com/workspaces/ws-1\n"+
+		"    sigv4:\n"+
+		"      region: eu-west-1\n")
+
+	backends, err := ReadBackends(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(backends) != 4 {
+		t.Fatalf("expected two backends, got %+v", backends)
+	}
+	if a := backends[0].BasicAuth; a == nil || a.Username != "user" || a.PasswordFile != filepath.Join(dir, "secrets/password") {
+		t.Errorf("unexpected basic auth %+v", a)
+	}
+	if tlsConfig := backends[0].TLS; tlsConfig == nil || tlsConfig.CAFile != "/etc/ca.pem" || !tlsConfig.InsecureSkipVerify {
+		t.Errorf("unexpected TLS config %+v", tlsConfig)
+	}
+	if b := backends[1].BearerToken; b == nil || b.Env != "CORTEX_TOKEN" {
+		t.Errorf("unexpected bearer token %+v", b)
+	}
+	if o := backends[2].OAuth2; o == nil || o.ClientID != "client" || o.ClientSecretFile != filepath.Join(dir, "secrets/client-secret") || len(o.Scopes) != 1 {
+		t.Errorf("unexpected OAuth2 config %+v", o)
+	}
+	if s := backends[3].SigV4; s == nil || s.Region != "eu-west-1" || s.service() != DefaultSigV4Service {
+		t.Errorf("unexpected SigV4 config %+v", s)
+	}
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cortex-client/pkg/ratelimiter"
+)
+
+// Backend is a Prometheus-compatible endpoint that queries are sent to
+type Backend struct {
+	// Name identifies the backend in merged output, defaulting to URL
+	Name string
+
+	// URL is the base URL of the Prometheus API, without the /api/v1 suffix
+	URL string
+
+	// Tenant is sent in the X-Scope-OrgID header expected by multi-tenant
+	// Cortex and Mimir. Tenants joined with | are queried together when the
+	// backend has tenant federation enabled
+	Tenant string
+
+	// Labels describe the backend, such as the external labels of the
+	// Prometheus behind it
+	Labels Labels
+
+	// Timeout overrides the client's backend timeout for this backend
+	Timeout time.Duration
+
+	// Weight orders backends, higher weights are queried and merged first
+	// so that they win conflicts under ConflictFirstWins
+	Weight int
+
+	// ReadyPath is the path of the readiness endpoint probed by health checks,
+	// from the root of the URL's host, such as /ready for Cortex and Mimir.
+	// It defaults to /-/ready under URL, where Prometheus serves it
+	ReadyPath string
+
+	// TimeWindow limits the queries sent to the backend to the time it holds
+	// data for, range queries covering more of it are split between backends
+	TimeWindow TimeWindow
+
+	// Limiter is acquired for every request to this backend, on top of the
+	// client's shared rate limiter
+	Limiter ratelimiter.RateLimiter
+
+	// BasicAuth, BearerToken, OAuth2 and SigV4 select how requests are
+	// authenticated, at most one of them or a custom Authenticator is set
+	BasicAuth     *BasicAuth
+	BearerToken   *BearerToken
+	OAuth2        *OAuth2
+	SigV4         *SigV4
+	Authenticator Authenticator
+
+	// TLS configures the connection to the backend. A backend with TLS
+	// settings or credentials gets a dedicated transport
+	TLS *TLSConfig
+
+	// httpClient is the backend's dedicated client, built by New
+	httpClient *http.Client
+}
+
+// String returns the backend's name, or its URL when it has none
+func (b Backend) String() string {
+	if b.Name != "" {
+		return b.Name
+	}
+	return b.URL
+}
+
+// readyURL is the URL of the readiness endpoint of the backend
+func (b Backend) readyURL() string {
+	if b.ReadyPath == "" {
+		return strings.TrimRight(b.URL, "/") + "/-/ready"
+	}
+	u, err := url.Parse(b.URL)
+	if err != nil {
+		return strings.TrimRight(b.URL, "/") + "/-/ready"
+	}
+	u.Path, u.RawPath, u.RawQuery, u.Fragment = b.ReadyPath, "", "", ""
+	return u.String()
+}
+
+// validate checks the backend settings that can be checked without sending
+// a request
+func (b Backend) validate() error {
+	if b.URL == "" {
+		return fmt.Errorf("backend %q has no URL", b.Name)
+	}
+	if b.Tenant != "" {
+		if err := ValidateTenant(b.Tenant); err != nil {
+			return fmt.Errorf("backend %s: %w", b, err)
+		}
+	}
+	if b.Timeout < 0 {
+		return fmt.Errorf("backend %s: timeout must not be negative", b)
+	}
+	if b.Weight < 0 {
+		return fmt.Errorf("backend %s: weight must not be negative", b)
+	}
+	if b.ReadyPath != "" && !strings.HasPrefix(b.ReadyPath, "/") {
+		return fmt.Errorf("backend %s: ready path %q must start with /", b, b.ReadyPath)
+	}
+	if err := b.TimeWindow.validate(); err != nil {
+		return fmt.Errorf("backend %s: %w", b, err)
+	}
+	if b.authenticators() > 1 {
+		return fmt.Errorf("backend %s: basic auth, bearer token, OAuth2, SigV4 and custom authenticators are mutually exclusive", b)
+	}
+	if b.BasicAuth != nil {
+		if err := b.BasicAuth.validate(); err != nil {
+			return fmt.Errorf("backend %s: %w", b, err)
+		}
+	}
+	if b.BearerToken != nil {
+		if err := b.BearerToken.validate(); err != nil {
+			return fmt.Errorf("backend %s: %w", b, err)
+		}
+	}
+	if b.OAuth2 != nil {
+		if err := b.OAuth2.validate(); err != nil {
+			return fmt.Errorf("backend %s: %w", b, err)
+		}
+	}
+	if b.SigV4 != nil {
+		if err := b.SigV4.validate(); err != nil {
+			return fmt.Errorf("backend %s: %w", b, err)
+		}
+	}
+	if b.TLS != nil {
+		if err := b.TLS.validate(); err != nil {
+			return fmt.Errorf("backend %s: %w", b, err)
+		}
+	}
+	return nil
+}
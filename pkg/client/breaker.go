@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a backend's circuit breaker
+type BreakerState string
+
+const (
+	// BreakerClosed lets every request through
+	BreakerClosed BreakerState = "closed"
+
+	// BreakerOpen skips the backend until the open timeout has passed
+	BreakerOpen BreakerState = "open"
+
+	// BreakerHalfOpen lets one probe request through at a time, closing the
+	// breaker when enough probes succeed and opening it again on a failure
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// DefaultBreakerConfig opens a backend's breaker after five consecutive
+// failed requests and probes the backend again after 30 seconds
+var DefaultBreakerConfig = BreakerConfig{
+	FailureThreshold:  5,
+	OpenTimeout:       30 * time.Second,
+	HalfOpenSuccesses: 1,
+}
+
+// BreakerConfig configures the circuit breakers kept for each backend URL.
+// Only failures that say something about the backend's health count: the
+// ones a request would be retried for
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens a
+	// breaker, zero disables circuit breaking
+	FailureThreshold int `yaml:"failure_threshold"`
+
+	// OpenTimeout is how long an open breaker skips its backend before
+	// letting a probe through
+	OpenTimeout time.Duration `yaml:"open_timeout"`
+
+	// HalfOpenSuccesses is the number of successful probes that closes a
+	// half-open breaker, defaulting to one
+	HalfOpenSuccesses int `yaml:"half_open_successes"`
+}
+
+func (b BreakerConfig) validate() error {
+	if b.FailureThreshold < 0 || b.OpenTimeout < 0 || b.HalfOpenSuccesses < 0 {
+		return errors.New("circuit breaker thresholds and timeouts must not be negative")
+	}
+	return nil
+}
+
+// BreakerStatus describes the circuit breaker of one backend URL
+type BreakerStatus struct {
+	URL   string       `json:"url"`
+	State BreakerState `json:"state"`
+
+	// Failures is the number of consecutive failures
+	Failures int `json:"failures"`
+
+	// OpenedAt is when the breaker last opened, zero if it never did
+	OpenedAt time.Time `json:"openedAt,omitzero"`
+
+	// LastError is the error of the last failure
+	LastError string `json:"lastError,omitempty"`
+}
+
+// breaker is the state of one backend URL's circuit breaker
+type breaker struct {
+	state     BreakerState
+	failures  int
+	successes int
+	probing   bool
+	openedAt  time.Time
+	lastError string
+}
+
+// breakerSet holds the circuit breakers of every backend URL a client sent
+// requests to
+type breakerSet struct {
+	config BreakerConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+func newBreakerSet(config BreakerConfig) *breakerSet {
+	if config.HalfOpenSuccesses == 0 {
+		config.HalfOpenSuccesses = 1
+	}
+	return &breakerSet{config: config, now: time.Now, breakers: make(map[string]*breaker)}
+}
+
+func (s *breakerSet) get(url string) *breaker {
+	b, ok := s.breakers[url]
+	if !ok {
+		b = &breaker{state: BreakerClosed}
+		s.breakers[url] = b
+	}
+	return b
+}
+
+// allow reports whether a request to url may be sent. An open breaker turns
+// half-open once its timeout has passed, and a half-open breaker lets a single
+// probe through. Every allowed request must be followed by a call to done
+func (s *breakerSet) allow(url string) bool {
+	if s.config.FailureThreshold == 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.get(url)
+	if b.state == BreakerOpen && s.now().Sub(b.openedAt) >= s.config.OpenTimeout {
+		b.state, b.successes = BreakerHalfOpen, 0
+	}
+	switch b.state {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// done records the result of a request allowed by allow. Errors that do not
+// reflect the backend's health, such as rejected queries, count as successes
+// and cancelled requests are not counted at all
+func (s *breakerSet) done(url string, err error) {
+	if s.config.FailureThreshold == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.get(url)
+	probe := b.probing
+	b.probing = false
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+		return
+	case err != nil && retryable(err):
+		b.failures++
+		b.lastError = err.Error()
+		if probe || (b.state == BreakerClosed && b.failures >= s.config.FailureThreshold) {
+			b.state, b.openedAt = BreakerOpen, s.now()
+		}
+	case b.state == BreakerHalfOpen:
+		b.successes++
+		if b.successes >= s.config.HalfOpenSuccesses {
+			b.state, b.failures = BreakerClosed, 0
+		}
+	default:
+		b.failures = 0
+	}
+}
+
+// statuses returns the state of every breaker, ordered by URL
+func (s *breakerSet) statuses() []BreakerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]BreakerStatus, 0, len(s.breakers))
+	for url, b := range s.breakers {
+		state := b.state
+		if state == BreakerOpen && s.now().Sub(b.openedAt) >= s.config.OpenTimeout {
+			// the next request will be a probe
+			state = BreakerHalfOpen
+		}
+		statuses = append(statuses, BreakerStatus{
+			URL:       url,
+			State:     state,
+			Failures:  b.failures,
+			OpenedAt:  b.openedAt,
+			LastError: b.lastError,
+		})
+	}
+	slices.SortFunc(statuses, func(a, b BreakerStatus) int {
+		return strings.Compare(a.URL, b.URL)
+	})
+	return statuses
+}
+
+// Breakers returns the circuit breaker state of every backend URL the client
+// has sent requests to
+func (c *Client) Breakers() []BreakerStatus {
+	return c.breakers.statuses()
+}
+
+// Breaker returns the circuit breaker state of a backend URL
+func (c *Client) Breaker(url string) BreakerStatus {
+	for _, s := range c.breakers.statuses() {
+		if s.URL == url {
+			return s
+		}
+	}
+	return BreakerStatus{URL: url, State: BreakerClosed}
+}
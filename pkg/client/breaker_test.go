@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreakerSet(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newBreakerSet(BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+	s.now = func() time.Time { return now }
+	const url = "http://prometheus"
+	failure := &APIError{StatusCode: http.StatusBadGateway, Msg: "bad gateway"}
+
+	for range 2 {
+		if !s.allow(url) {
+			t.Fatal("expected a closed breaker to allow requests")
+		}
+		s.done(url, failure)
+	}
+	if s.allow(url) {
+		t.Fatal("expected the breaker to open after two failures")
+	}
+	if st := s.statuses()[0]; st.State != BreakerOpen || st.Failures != 2 || !st.OpenedAt.Equal(now) || st.LastError != failure.Error() {
+		t.Errorf("unexpected status %+v", st)
+	}
+
+	now = now.Add(time.Minute)
+	if !s.allow(url) {
+		t.Fatal("expected a probe after the open timeout")
+	}
+	if s.allow(url) {
+		t.Fatal("expected a single probe at a time")
+	}
+	s.done(url, failure)
+	if s.allow(url) {
+		t.Fatal("expected a failed probe to open the breaker again")
+	}
+
+	now = now.Add(time.Minute)
+	if !s.allow(url) {
+		t.Fatal("expected a probe after the open timeout")
+	}
+	s.done(url, context.Canceled)
+	if !s.allow(url) {
+		t.Fatal("expected a cancelled probe to let another one through")
+	}
+	// rejected queries say nothing about the backend's health
+	s.done(url, &APIError{StatusCode: http.StatusBadRequest, Type: ErrorTypeBadData})
+	if st := s.statuses()[0]; st.State != BreakerClosed || st.Failures != 0 {
+		t.Errorf("expected a successful probe to close the breaker, got %+v", st)
+	}
+
+	disabled := newBreakerSet(BreakerConfig{})
+	for range 10 {
+		disabled.done(url, failure)
+	}
+	if !disabled.allow(url) {
+		t.Error("expected a disabled breaker to allow every request")
+	}
+}
+
+func TestClientQuery_SkipsOpenBreaker(t *testing.T) {
+	var calls atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(mockPrometheusHandler(t)))
+	defer up.Close()
+
+	c, err := New(
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithCircuitBreaker(BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Hour}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := QueryData{Query: "up", Backends: []string{up.URL, down.URL}}
+	for range 2 {
+		if _, err := c.Query(context.Background(), data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	merged, err := c.Query(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected the open breaker to skip the backend, got %d calls", calls.Load())
+	}
+	if s := merged.Backends[1]; s.Status != BackendStatusSkipped || s.Attempts != 0 {
+		t.Errorf("expected the backend to be skipped, got %+v", s)
+	}
+	if len(merged.Warnings) != 1 || merged.Warnings[0] != "backend "+down.URL+" skipped: circuit breaker open" {
+		t.Errorf("unexpected warnings %v", merged.Warnings)
+	}
+	if b := c.Breaker(down.URL); b.State != BreakerOpen || b.Failures != 2 {
+		t.Errorf("unexpected breaker %+v", b)
+	}
+	if b := c.Breaker(up.URL); b.State != BreakerClosed {
+		t.Errorf("unexpected breaker %+v", b)
+	}
+	if len(c.Breakers()) != 2 {
+		t.Errorf("expected a breaker per backend URL, got %+v", c.Breakers())
+	}
+}
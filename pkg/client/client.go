@@ -1,135 +1,635 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"os"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/cortex-client/pkg/ratelimiter"
-	"gopkg.in/yaml.v2"
 )
 
+// PrometheusResponse is the decoded body of a Prometheus query API response
 type PrometheusResponse struct {
-	Status string          `json:"status"`
-	Data   json.RawMessage `json:"data"`
+	Status    string     `json:"status"`
+	Data      ResultData `json:"data"`
+	ErrorType string     `json:"errorType,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Warnings  []string   `json:"warnings,omitempty"`
 }
 
 type QueryData struct {
 	Query    string
 	Backends []string
+
+	// Merge controls how series returned by several backends are combined,
+	// defaulting to the client's merge strategy when left empty
+	Merge MergeStrategy
+
+	// PartialResponse decides whether failed backends fail the whole query,
+	// defaulting to PartialResponseLenient
+	PartialResponse PartialResponseStrategy
+
+	// Time is the evaluation time of an instant query. Merged queries default
+	// it to the moment they start so every backend evaluates at the same time
+	Time time.Time
+
+	// Start, End and Step turn the query into a range query when Start is set
+	Start time.Time
+	End   time.Time
+	Step  time.Duration
+
+	// Timeout bounds the whole merged query, zero means no deadline
+	Timeout time.Duration
+
+	// BackendTimeout bounds each backend request and is forwarded to the
+	// backend as the Prometheus timeout parameter, zero means no deadline
+	BackendTimeout time.Duration
+
+	// Tenants sends the query to every backend once per tenant, overriding
+	// the backend's own tenant, and labels each series with TenantLabel
+	Tenants []string
+
+	// Tenant overrides the tenant of every backend, as if each had it set
+	Tenant string
+
+	// Mode decides how the backends are used, defaulting to QueryModeMerge
+	Mode QueryMode
+
+	// Quorum is the number of backends that must agree in QueryModeQuorum,
+	// defaulting to a majority of them
+	Quorum int
+
+	// Tolerance is how far apart values may be for backends to agree in
+	// QueryModeQuorum
+	Tolerance Tolerance
+
+	// now is when the query started, which backend time windows are relative to
+	now time.Time
 }
 
-type PrometheusQueryJob struct {
-	BackendURL string
-	Query      string
+// IsRange reports whether the query should be sent to the range query endpoint
+func (q QueryData) IsRange() bool {
+	return !q.Start.IsZero()
 }
 
-func prometheusQueryWorker(jobs <-chan PrometheusQueryJob, results chan<- *PrometheusResponse, wg *sync.WaitGroup, r ratelimiter.RateLimiter) {
-	for job := range jobs {
-		token, err := r.Acquire()
-		fmt.Printf("Rate Limit Token %s acquired at %s...\n", token.ID, time.Now().UTC())
+// Client queries and merges results from a set of Prometheus backends. A
+// Client is safe for concurrent use and should be reused across queries so
+// that its rate limiter applies to all of them
+type Client struct {
+	httpClient      *http.Client
+	workers         int
+	limiter         ratelimiter.RateLimiter
+	retry           RetryPolicy
+	breakerConfig   BreakerConfig
+	breakers        *breakerSet
+	health          healthSet
+	hedge           HedgePolicy
+	latencies       latencyTracker
+	logger          *log.Logger
+	backends        []Backend
+	groups          map[string][]string
+	merge           MergeStrategy
+	partialResponse PartialResponseStrategy
+	timeout         time.Duration
+	backendTimeout  time.Duration
+	method          RequestMethod
+}
+
+// New creates a Client configured by opts
+func New(opts ...Option) (*Client, error) {
+	c := &Client{
+		httpClient:    http.DefaultClient,
+		workers:       DefaultWorkers,
+		retry:         DefaultRetryPolicy,
+		breakerConfig: DefaultBreakerConfig,
+		hedge:         DefaultHedgePolicy,
+		logger:        log.Default(),
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	if c.limiter == nil {
+		limiter, err := ratelimiter.NewMaxConcurrencyRateLimiter(&ratelimiter.Config{
+			Limit:            DefaultRateLimit,
+			TokenResetsAfter: DefaultTokenResetsAfter,
+		})
 		if err != nil {
-			panic(err)
+			return nil, err
+		}
+		c.limiter = limiter
+	}
+	c.breakers = newBreakerSet(c.breakerConfig)
+	for name, members := range c.groups {
+		for _, member := range members {
+			if !slices.ContainsFunc(c.backends, func(b Backend) bool { return b.Name == member || b.URL == member }) {
+				return nil, fmt.Errorf("group %s refers to unknown backend %q", name, member)
+			}
 		}
-		resp, err := QueryPrometheus(job.BackendURL, job.Query)
+	}
+	for i, b := range c.backends {
+		httpClient, err := newBackendHTTPClient(c.httpClient, b)
 		if err != nil {
-			log.Printf("error querying backend %s: %v", job.BackendURL, err)
-			continue
+			return nil, fmt.Errorf("backend %s: %w", b, err)
+		}
+		c.backends[i].httpClient = httpClient
+	}
+	return c, nil
+}
+
+// defaultClient backs the package-level query functions
+var defaultClient = func() *Client {
+	c, err := New()
+	if err != nil {
+		panic(err)
+	}
+	return c
+}()
+
+// Backends returns the backends the client was configured with
+func (c *Client) Backends() []Backend {
+	return slices.Clone(c.backends)
+}
+
+// backendsFor resolves the backends a request should be sent to. References
+// are matched against groups, whose members are expanded, and against
+// configured backends by name or URL so that their settings apply. Anything
+// else is treated as a bare URL. Without any references the configured
+// backends are used. Backends are ordered by descending weight
+func (c *Client) backendsFor(refs []string) []Backend {
+	var backends []Backend
+	if len(refs) == 0 {
+		backends = c.Backends()
+	}
+	for _, ref := range refs {
+		members, ok := c.groups[ref]
+		if !ok {
+			members = []string{ref}
+		}
+		for _, member := range members {
+			if member != "" {
+				backends = append(backends, c.backendFor(member))
+			}
+		}
+	}
+	sort.SliceStable(backends, func(i, j int) bool {
+		return backends[i].Weight > backends[j].Weight
+	})
+	return backends
+}
+
+// queryBackends resolves the backends of data, setting data.Tenant on them
+// when given
+func (c *Client) queryBackends(data QueryData) []Backend {
+	backends := c.backendsFor(data.Backends)
+	if data.Tenant != "" {
+		for i := range backends {
+			backends[i].Tenant = data.Tenant
+		}
+	}
+	return backends
+}
+
+// backendFor returns the configured backend named or located at ref, or a
+// bare backend with ref as its URL
+func (c *Client) backendFor(ref string) Backend {
+	for _, b := range c.backends {
+		if b.Name == ref || b.URL == ref {
+			return b
 		}
-		results <- resp
-		wg.Done()
 	}
+	return Backend{URL: ref}
+}
+
+// withDefaults fills the unset fields of data from the client configuration
+func (c *Client) withDefaults(data QueryData) QueryData {
+	if data.Merge == (MergeStrategy{}) {
+		data.Merge = c.merge
+	}
+	if data.PartialResponse == "" {
+		data.PartialResponse = c.partialResponse
+	}
+	if data.Timeout == 0 {
+		data.Timeout = c.timeout
+	}
+	if data.BackendTimeout == 0 {
+		data.BackendTimeout = c.backendTimeout
+	}
+	return data
 }
 
-// ReadBackendFile reads a YAML file with prometheus_backends as a list
+// ReadBackendFile reads the backends of a configuration file and returns
+// their URLs
 func ReadBackendFile(path string) ([]string, error) {
-	data, err := os.ReadFile(path)
+	backends, err := ReadBackends(path)
 	if err != nil {
 		return nil, err
 	}
-	var parsed struct {
-		PrometheusBackends []string `yaml:"prometheus_backends"`
+	urls := make([]string, len(backends))
+	for i, b := range backends {
+		urls[i] = b.URL
 	}
-	if err := yaml.Unmarshal(data, &parsed); err != nil {
+	return urls, nil
+}
+
+// ReadBackends reads the backends of a configuration file, see LoadConfig
+func ReadBackends(path string) ([]Backend, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
 		return nil, err
 	}
-	return parsed.PrometheusBackends, nil
+	backends := make([]Backend, 0, len(cfg.Backends))
+	for _, bc := range cfg.Backends {
+		b, err := bc.Backend()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		backends = append(backends, b)
+	}
+	return backends, nil
 }
 
 // QueryPrometheus queries a single Prometheus backend
 func QueryPrometheus(backendURL, query string) (*PrometheusResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/query?query=%s", strings.TrimRight(backendURL, "/"), query)
-	resp, err := http.Get(url)
+	return QueryPrometheusWithContext(context.Background(), backendURL, query)
+}
+
+// QueryPrometheusWithContext queries a single Prometheus backend, aborting the
+// request when ctx is done
+func QueryPrometheusWithContext(ctx context.Context, backendURL, query string) (*PrometheusResponse, error) {
+	return defaultClient.QueryPrometheus(ctx, backendURL, query)
+}
+
+// QueryPrometheusRange runs a range query against a single Prometheus backend
+func QueryPrometheusRange(backendURL, query string, start, end time.Time, step time.Duration) (*PrometheusResponse, error) {
+	return QueryPrometheusRangeWithContext(context.Background(), backendURL, query, start, end, step)
+}
+
+// QueryPrometheusRangeWithContext runs a range query against a single
+// Prometheus backend, aborting the request when ctx is done
+func QueryPrometheusRangeWithContext(ctx context.Context, backendURL, query string, start, end time.Time, step time.Duration) (*PrometheusResponse, error) {
+	return defaultClient.QueryPrometheusRange(ctx, backendURL, query, start, end, step)
+}
+
+// QueryPrometheus queries a single Prometheus backend, evaluating the query
+// at the current time and aborting the request when ctx is done. A backend
+// configured with backendURL as its URL or name is queried with its settings
+func (c *Client) QueryPrometheus(ctx context.Context, backendURL, query string) (*PrometheusResponse, error) {
+	return c.QueryPrometheusAt(ctx, backendURL, query, time.Now())
+}
+
+// QueryPrometheusAt queries a single Prometheus backend, evaluating the query
+// at ts
+func (c *Client) QueryPrometheusAt(ctx context.Context, backendURL, query string, ts time.Time) (*PrometheusResponse, error) {
+	return c.queryAt(ctx, c.backendFor(backendURL), query, ts)
+}
+
+func (c *Client) queryAt(ctx context.Context, b Backend, query string, ts time.Time) (*PrometheusResponse, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("time", formatTime(ts))
+	return c.callPrometheus(ctx, b, "/api/v1/query", params)
+}
+
+// QueryPrometheusRange runs a range query against a single Prometheus
+// backend, aborting the request when ctx is done
+func (c *Client) QueryPrometheusRange(ctx context.Context, backendURL, query string, start, end time.Time, step time.Duration) (*PrometheusResponse, error) {
+	return c.queryRange(ctx, c.backendFor(backendURL), query, start, end, step)
+}
+
+func (c *Client) queryRange(ctx context.Context, b Backend, query string, start, end time.Time, step time.Duration) (*PrometheusResponse, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("range query step must be greater than zero, got %s", step)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("range query end %s is before start %s", end, start)
+	}
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", formatTime(start))
+	params.Set("end", formatTime(end))
+	params.Set("step", formatDuration(step))
+	return c.callPrometheus(ctx, b, "/api/v1/query_range", params)
+}
+
+// formatTime renders t as fractional unix seconds, as the Prometheus API expects
+func formatTime(t time.Time) string {
+	return formatTimestamp(t.UnixMilli())
+}
+
+// formatDuration renders d as fractional seconds
+func formatDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// newRequest builds a form-encoded API request for b. A deadline on ctx is
+// forwarded as the Prometheus timeout parameter, the parameters go in a POST
+// body when the client's request method says so and the backend's tenant is
+// sent in the X-Scope-OrgID header
+func (c *Client) newRequest(ctx context.Context, b Backend, path string, params url.Values) (*http.Request, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline).Truncate(time.Millisecond); remaining > 0 {
+			params.Set("timeout", formatDuration(remaining))
+		}
+	}
+	endpoint := strings.TrimRight(b.URL, "/") + path
+	encoded := params.Encode()
+	var req *http.Request
+	var err error
+	if c.method.usePOST(len(encoded)) {
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(encoded))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		if encoded != "" {
+			endpoint += "?" + encoded
+		}
+		if req, err = http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil); err != nil {
+			return nil, err
+		}
+	}
+	if b.Tenant != "" {
+		req.Header.Set(TenantHeader, b.Tenant)
+	}
+	return req, nil
+}
+
+// httpClientFor returns the backend's dedicated HTTP client, or the shared one
+func (c *Client) httpClientFor(b Backend) *http.Client {
+	if b.httpClient != nil {
+		return b.httpClient
+	}
+	return c.httpClient
+}
+
+// apiResponse is the envelope shared by every Prometheus API endpoint
+type apiResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Warnings  []string        `json:"warnings,omitempty"`
+}
+
+// callPrometheus sends a query request and decodes its result data
+func (c *Client) callPrometheus(ctx context.Context, b Backend, path string, params url.Values) (*PrometheusResponse, error) {
+	envelope, err := c.callAPI(ctx, b, path, params)
+	if err != nil {
+		return nil, err
+	}
+	result := &PrometheusResponse{Status: envelope.Status, Warnings: envelope.Warnings}
+	if err := json.Unmarshal(envelope.Data, &result.Data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// callAPI sends a request and decodes the response envelope, returning an
+// *APIError for non-2xx responses and Prometheus error documents
+func (c *Client) callAPI(ctx context.Context, b Backend, path string, params url.Values) (*apiResponse, error) {
+	req, err := c.newRequest(ctx, b, path, params)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClientFor(b).Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer func() {
 		if cerr := resp.Body.Close(); cerr != nil {
-			// Optionally log or handle the error, e.g.:
-			fmt.Printf("error closing response body: %v\n", cerr)
+			c.logger.Printf("error closing response body: %v", cerr)
 		}
 	}()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
-	var result PrometheusResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
+	var result apiResponse
+	decodeErr := json.Unmarshal(body, &result)
+	if resp.StatusCode/100 != 2 {
+		if decodeErr != nil {
+			return nil, newAPIError(resp.StatusCode, body, nil)
+		}
+		return nil, newAPIError(resp.StatusCode, body, &result)
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	if result.Status == "error" {
+		return nil, newAPIError(resp.StatusCode, body, &result)
 	}
 	return &result, nil
 }
 
-// MergePrometheusQueries queries all backends and merges the results
+// MergedResponse is a Prometheus-compatible response built from several
+// backends, extended with the outcome of each backend
+type MergedResponse struct {
+	Status   string          `json:"status"`
+	Data     ResultData      `json:"data"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Backends []BackendStatus `json:"backends,omitempty"`
+
+	// EvaluationTime is the time every backend evaluated an instant query at
+	EvaluationTime *time.Time `json:"evaluationTime,omitempty"`
+
+	// AnsweredBy is the backend whose answer was returned by the modes that
+	// do not merge results
+	AnsweredBy string `json:"answeredBy,omitempty"`
+
+	// Quorum reports how the backends agreed in QueryModeQuorum
+	Quorum *QuorumReport `json:"quorum,omitempty"`
+}
+
+// MergePrometheusQueries queries all backends and merges the results into a
+// single Prometheus-compatible document. Range queries are used when
+// data.Start is set, in which case the matrix results are merged. Failed
+// backends are handled according to data.PartialResponse: a
+// *PartialResponseError is returned in strict mode or when every backend
+// failed, otherwise each failure is reported as a warning
 func MergePrometheusQueries(data QueryData) ([]byte, error) {
-	var merged struct {
-		Status string            `json:"status"`
-		Data   []json.RawMessage `json:"data"`
+	return MergePrometheusQueriesWithContext(context.Background(), data)
+}
+
+// MergePrometheusQueriesWithContext is MergePrometheusQueries with a context.
+// Cancelling ctx, or reaching data.Timeout, aborts in-flight backend requests
+// and any wait for a rate limit token
+func MergePrometheusQueriesWithContext(ctx context.Context, data QueryData) ([]byte, error) {
+	return defaultClient.MergePrometheusQueries(ctx, data)
+}
+
+// MergePrometheusQueries runs Query and encodes the merged response as JSON
+func (c *Client) MergePrometheusQueries(ctx context.Context, data QueryData) ([]byte, error) {
+	merged, err := c.Query(ctx, data)
+	if err != nil {
+		return nil, err
 	}
+	return json.MarshalIndent(merged, "", "  ")
+}
 
-	r, err := ratelimiter.NewMaxConcurrencyRateLimiter(&ratelimiter.Config{
-		Limit:            100,
-		TokenResetsAfter: 10 * time.Second,
-	})
+// Query sends data.Query to every backend and merges the results, unless
+// data.Mode picks a single backend to answer. When data.Backends is empty the
+// client's configured backends are used, and unset fields of data take the
+// client's defaults. Backends that cannot hold data for the query are left
+// out, see Explain. Instant queries are evaluated at one pinned timestamp on
+// every backend, which is echoed in the response
+func (c *Client) Query(ctx context.Context, data QueryData) (*MergedResponse, error) {
+	data = c.withDefaults(data)
+	for _, tenant := range data.Tenants {
+		if err := ValidateTenant(tenant); err != nil {
+			return nil, err
+		}
+	}
+	if data.Tenant != "" {
+		if err := ValidateTenant(data.Tenant); err != nil {
+			return nil, err
+		}
+	}
+	mode, err := ParseQueryMode(string(data.Mode))
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	data.Mode = mode
+	if data.Mode != QueryModeMerge && len(data.Tenants) > 0 {
+		return nil, fmt.Errorf("queries can only fan out to tenants in %s mode, not %s", QueryModeMerge, data.Mode)
+	}
+	data = pinTimes(data)
+	// merged range queries are split between backends by time and stitched back
+	backends, decisions := route(c.queryBackends(data), data, data.Mode == QueryModeMerge)
+	if len(backends) == 0 && len(decisions) > 0 {
+		return nil, noRouteError(decisions)
+	}
+	data.Merge.stitch = data.Mode == QueryModeMerge && timeRouted(backends)
+	if data.Mode == QueryModeQuorum {
+		if data.Quorum == 0 {
+			data.Quorum = defaultQuorum(len(backends))
+		}
+		if data.Quorum < 1 || data.Quorum > len(backends) {
+			return nil, fmt.Errorf("quorum must be between 1 and the %d backends queried, got %d", len(backends), data.Quorum)
+		}
+	}
+	merged := &MergedResponse{Status: "success"}
+	if !data.IsRange() {
+		merged.EvaluationTime = &data.Time
+	}
+
+	if data.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, data.Timeout)
+		defer cancel()
 	}
+	call := c.queryCall(data)
 
-	merged.Status = "success"
-	numWorkers := 5
+	var succeeded []backendResult
+	var statuses []BackendStatus
+	var warnings []string
+	switch data.Mode {
+	case QueryModeHedge:
+		succeeded, statuses, warnings, err = settleFirst(hedge(ctx, c, backends, data.BackendTimeout, call))
+	case QueryModeFailover:
+		succeeded, statuses, warnings, err = settleFirst(failover(ctx, c, backends, data.BackendTimeout, call))
+	case QueryModeQuorum:
+		succeeded, statuses, warnings, err = settle(fanOut(ctx, c, backends, data.BackendTimeout, call), data.PartialResponse)
+		if err == nil {
+			var report QuorumReport
+			succeeded, report, err = checkQuorum(succeeded, data.Quorum, data.Tolerance)
+			merged.Quorum = &report
+			for _, d := range report.Disagreements {
+				warning := fmt.Sprintf("backend %s disagrees with backend %s", d.Backend, d.Reference)
+				if len(d.Differences) > 0 {
+					warning += fmt.Sprintf(": %s", d.Differences[0])
+				}
+				if more := len(d.Differences) - 1; more > 0 {
+					warning += fmt.Sprintf(" and %d more", more)
+				}
+				warnings = append(warnings, warning)
+			}
+		}
+	default:
+		outcomes := fanOut(ctx, c, forTenants(backends, data.Tenants), data.BackendTimeout, call)
+		succeeded, statuses, warnings, err = settle(outcomes, data.PartialResponse)
+	}
+	merged.Backends, merged.Warnings = statuses, warnings
+	if err != nil {
+		return nil, err
+	}
+	if data.Mode != QueryModeMerge {
+		// the first agreeing backend stands for the quorum
+		succeeded = succeeded[:1]
+		merged.AnsweredBy = succeeded[0].Backend
+	}
 
-	jobs := make(chan PrometheusQueryJob, len(data.Backends))
-	results := make(chan *PrometheusResponse, len(data.Backends))
-	var wg sync.WaitGroup
+	emptyType := ResultTypeVector
+	if data.IsRange() {
+		emptyType = ResultTypeMatrix
+	}
+	merged.Data, err = mergeResults(succeeded, emptyType, data.Merge)
+	if err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
 
-	for range numWorkers {
-		go prometheusQueryWorker(jobs, results, &wg, r)
+// pinTimes sets the end of a range query and the evaluation time of an
+// instant query to now when they are unset, so every backend is sent the same
+func pinTimes(data QueryData) QueryData {
+	data.now = time.Now()
+	if data.IsRange() {
+		if data.End.IsZero() {
+			data.End = data.now
+		}
+	} else if data.Time.IsZero() {
+		// millisecond precision matches what is sent to the backends
+		data.Time = time.UnixMilli(data.now.UnixMilli())
 	}
+	return data
+}
 
-	wg.Add(len(data.Backends))
-	for _, backend := range data.Backends {
-		if backend == "" {
-			continue
+// queryCall returns the request running data against one backend, labelling
+// the series with the backend's tenant when querying several tenants. Range
+// queries only ask a backend for the part of the range in its time window
+func (c *Client) queryCall(data QueryData) backendCall[*PrometheusResponse] {
+	return func(ctx context.Context, b Backend) (*PrometheusResponse, []string, error) {
+		var resp *PrometheusResponse
+		var err error
+		if data.IsRange() {
+			start, end := data.Start, data.End
+			if !b.TimeWindow.IsZero() {
+				start, end, _ = b.TimeWindow.span(data.now, data.Start, data.End, data.Step)
+			}
+			resp, err = c.queryRange(ctx, b, data.Query, start, end, data.Step)
+		} else {
+			resp, err = c.queryAt(ctx, b, data.Query, data.Time)
 		}
-		jobs <- PrometheusQueryJob{
-			BackendURL: backend,
-			Query:      data.Query,
+		if err != nil {
+			return nil, nil, err
 		}
+		if len(data.Tenants) > 0 {
+			resp.Data = withResultLabel(resp.Data, TenantLabel, b.Tenant)
+		}
+		return resp, resp.Warnings, nil
 	}
-	close(jobs)
-	wg.Wait()
+}
 
-	for range data.Backends {
-		resp := <-results
-		merged.Data = append(merged.Data, resp.Data)
+// appendUnique appends the values not already present in list
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
 	}
-	return json.MarshalIndent(merged, "", "  ")
+	return list
 }
 
 // SplitAndTrim splits a comma-separated string and trims spaces
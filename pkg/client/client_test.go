@@ -1,13 +1,16 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSplitAndTrim(t *testing.T) {
@@ -81,18 +84,15 @@ func TestMergePrometheusQueries_EmptyBackends(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	var merged struct {
-		Status string            `json:"status"`
-		Data   []json.RawMessage `json:"data"`
-	}
+	var merged MergedResponse
 	if err := json.Unmarshal(output, &merged); err != nil {
 		t.Fatalf("failed to unmarshal: %v", err)
 	}
 	if merged.Status != "success" {
 		t.Errorf("expected status 'success', got %q", merged.Status)
 	}
-	if len(merged.Data) != 0 {
-		t.Errorf("expected no data, got %d", len(merged.Data))
+	if merged.Data.Type != ResultTypeVector || len(merged.Data.Vector) != 0 {
+		t.Errorf("expected an empty vector, got %+v", merged.Data)
 	}
 }
 
@@ -159,3 +159,223 @@ func TestClientHitsMockPrometheus(t *testing.T) {
 
 	t.Log("Test passed: client received a successful response from the mock Prometheus API.")
 }
+
+func TestQueryPrometheusRange_MockServer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			t.Errorf("expected range endpoint, got %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("start") != "1700000000" || q.Get("end") != "1700000060.5" || q.Get("step") != "15" {
+			t.Errorf("unexpected range parameters: %v", q)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	start := time.Unix(1700000000, 0)
+	end := start.Add(60*time.Second + 500*time.Millisecond)
+	resp, err := QueryPrometheusRange(ts.URL, "up", start, end, 15*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != "success" {
+		t.Errorf("expected status 'success', got %q", resp.Status)
+	}
+}
+
+func TestQueryPrometheusRange_InvalidParameters(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	if _, err := QueryPrometheusRange("http://localhost:9090", "up", start, start.Add(time.Minute), 0); err == nil {
+		t.Error("expected error for zero step, got nil")
+	}
+	if _, err := QueryPrometheusRange("http://localhost:9090", "up", start, start.Add(-time.Minute), time.Second); err == nil {
+		t.Error("expected error for end before start, got nil")
+	}
+}
+
+func TestMergePrometheusQueries_Range(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"job":"a"},"values":[[1700000000,"1"]]}]}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	output, err := MergePrometheusQueries(QueryData{
+		Query:    "up",
+		Backends: []string{ts.URL, ts.URL},
+		Start:    time.Unix(1700000000, 0),
+		End:      time.Unix(1700000060, 0),
+		Step:     15 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var merged MergedResponse
+	if err := json.Unmarshal(output, &merged); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if merged.Data.Type != ResultTypeMatrix || len(merged.Data.Matrix) != 1 {
+		t.Errorf("expected one merged matrix series from both backends, got:\n%s", output)
+	}
+}
+
+func TestQueryPrometheus_APIError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		if _, err := w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	_, err := QueryPrometheus(ts.URL, "up")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Type != "bad_data" || apiErr.Msg != "parse error" {
+		t.Errorf("unexpected API error: %+v", apiErr)
+	}
+}
+
+func TestMergePrometheusQueries_PartialResponse(t *testing.T) {
+	good := httptest.NewServer(mockPrometheusHandler(t))
+	defer good.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	defer bad.Close()
+
+	t.Run("lenient returns what succeeded", func(t *testing.T) {
+		output, err := MergePrometheusQueries(QueryData{Query: "up", Backends: []string{good.URL, bad.URL}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var merged MergedResponse
+		if err := json.Unmarshal(output, &merged); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		if merged.Status != "success" || len(merged.Warnings) != 1 {
+			t.Errorf("expected success with one warning, got %+v", merged)
+		}
+		if len(merged.Backends) != 2 || merged.Backends[0].Status != BackendStatusSuccess {
+			t.Fatalf("unexpected backend statuses: %+v", merged.Backends)
+		}
+		if failed := merged.Backends[1]; failed.Status != BackendStatusError || failed.HTTPStatus != http.StatusBadGateway || failed.Error == "" {
+			t.Errorf("unexpected failed backend status: %+v", failed)
+		}
+	})
+
+	t.Run("strict fails the query", func(t *testing.T) {
+		_, err := MergePrometheusQueries(QueryData{Query: "up", Backends: []string{good.URL, bad.URL}, PartialResponse: PartialResponseStrict})
+		var partialErr *PartialResponseError
+		if !errors.As(err, &partialErr) {
+			t.Fatalf("expected *PartialResponseError, got %v", err)
+		}
+		if errors.Is(err, ErrNoBackendSucceeded) {
+			t.Error("expected one backend to have succeeded")
+		}
+	})
+
+	t.Run("all backends failing is an error", func(t *testing.T) {
+		_, err := MergePrometheusQueries(QueryData{Query: "up", Backends: []string{bad.URL, "http://invalid:9999"}})
+		if !errors.Is(err, ErrNoBackendSucceeded) {
+			t.Fatalf("expected ErrNoBackendSucceeded, got %v", err)
+		}
+	})
+}
+
+func TestMergePrometheusQueriesWithContext_BackendTimeout(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("timeout") == "" {
+			t.Errorf("expected the backend timeout to be forwarded, got %v", r.URL.Query())
+		}
+		mockPrometheusHandler(t)(w, r)
+	}))
+	defer fast.Close()
+	stalled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer stalled.Close()
+
+	started := time.Now()
+	output, err := MergePrometheusQueriesWithContext(context.Background(), QueryData{
+		Query:          "up",
+		Backends:       []string{fast.URL, stalled.URL},
+		BackendTimeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("expected the stalled backend to time out, query took %s", elapsed)
+	}
+	var merged MergedResponse
+	if err := json.Unmarshal(output, &merged); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if merged.Backends[1].Status != BackendStatusError || !strings.Contains(merged.Backends[1].Error, "deadline exceeded") {
+		t.Errorf("expected the stalled backend to report a deadline error, got %+v", merged.Backends[1])
+	}
+}
+
+func TestMergePrometheusQueriesWithContext_Cancelled(t *testing.T) {
+	stalled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer stalled.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err := MergePrometheusQueriesWithContext(ctx, QueryData{Query: "up", Backends: []string{stalled.URL}})
+	if !errors.Is(err, ErrNoBackendSucceeded) {
+		t.Fatalf("expected ErrNoBackendSucceeded after cancellation, got %v", err)
+	}
+}
+
+func TestClientQuery_PinsEvaluationTime(t *testing.T) {
+	times := make(chan string, 3)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times <- r.URL.Query().Get("time")
+		mockPrometheusHandler(t)(w, r)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithWorkers(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	merged, err := c.Query(context.Background(), QueryData{Query: "up", Backends: []string{ts.URL, ts.URL, ts.URL}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merged.EvaluationTime == nil {
+		t.Fatal("expected the evaluation time in the merged response")
+	}
+	want := formatTime(*merged.EvaluationTime)
+	for range 3 {
+		if got := <-times; got != want {
+			t.Errorf("expected every backend to evaluate at %s, got %s", want, got)
+		}
+	}
+
+	pinned := time.Unix(1700000000, 0)
+	merged, err = c.Query(context.Background(), QueryData{Query: "up", Backends: []string{ts.URL}, Time: pinned})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := <-times; got != "1700000000" || !merged.EvaluationTime.Equal(pinned) {
+		t.Errorf("expected the explicit time to be used and echoed, got %s and %s", got, merged.EvaluationTime)
+	}
+}
@@ -0,0 +1,250 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tolerance is how far apart two sample values may be and still be considered
+// equal. Values match when they are within Absolute of each other or when
+// their difference is within Relative of the larger one
+type Tolerance struct {
+	Absolute float64
+	Relative float64
+}
+
+// ParseTolerance converts a tolerance such as "0.5", an absolute difference,
+// or "1%", a relative one, into a Tolerance. An empty string requires exact
+// equality
+func ParseTolerance(s string) (Tolerance, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Tolerance{}, nil
+	}
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		v, err := strconv.ParseFloat(pct, 64)
+		if err != nil || v < 0 || math.IsNaN(v) {
+			return Tolerance{}, fmt.Errorf("invalid relative tolerance %q, expected a non-negative percentage", s)
+		}
+		return Tolerance{Relative: v / 100}, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 || math.IsNaN(v) {
+		return Tolerance{}, fmt.Errorf("invalid tolerance %q, expected a non-negative number or percentage", s)
+	}
+	return Tolerance{Absolute: v}, nil
+}
+
+// String renders the tolerance in the form accepted by ParseTolerance
+func (t Tolerance) String() string {
+	if t.Relative > 0 {
+		return strconv.FormatFloat(t.Relative*100, 'f', -1, 64) + "%"
+	}
+	return strconv.FormatFloat(t.Absolute, 'f', -1, 64)
+}
+
+// equal reports whether a and b match within the tolerance. NaN matches NaN
+// since Prometheus returns it for the same undefined results
+func (t Tolerance) equal(a, b float64) bool {
+	if a == b || (math.IsNaN(a) && math.IsNaN(b)) {
+		return true
+	}
+	d := math.Abs(a - b)
+	return d <= t.Absolute || d <= t.Relative*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// DiffKind classifies a difference between two results
+type DiffKind string
+
+const (
+	// DiffType is a result of a different type, no further comparison is made
+	DiffType DiffKind = "type"
+
+	// DiffMissing is a series of the reference absent from the other result
+	DiffMissing DiffKind = "missing"
+
+	// DiffExtra is a series of the other result absent from the reference
+	DiffExtra DiffKind = "extra"
+
+	// DiffValue is a sample whose values differ beyond the tolerance
+	DiffValue DiffKind = "value"
+
+	// DiffGap is a sample of a range present in only one of the results
+	DiffGap DiffKind = "gap"
+)
+
+// Difference is one way in which a result differs from a reference result.
+// Values are rendered as Prometheus does, an empty one is a sample that is
+// absent
+type Difference struct {
+	Kind   DiffKind
+	Metric Labels
+
+	// Time of the differing sample, zero for series and type differences
+	Time time.Time
+
+	Expected string
+	Got      string
+}
+
+type differenceJSON struct {
+	Kind     DiffKind   `json:"kind"`
+	Metric   Labels     `json:"metric,omitempty"`
+	Time     *time.Time `json:"time,omitempty"`
+	Expected string     `json:"expected,omitempty"`
+	Got      string     `json:"got,omitempty"`
+}
+
+// MarshalJSON leaves out the time of series and type differences
+func (d Difference) MarshalJSON() ([]byte, error) {
+	raw := differenceJSON{Kind: d.Kind, Metric: d.Metric, Expected: d.Expected, Got: d.Got}
+	if !d.Time.IsZero() {
+		t := d.Time.UTC()
+		raw.Time = &t
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON decodes a difference encoded by MarshalJSON
+func (d *Difference) UnmarshalJSON(b []byte) error {
+	var raw differenceJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*d = Difference{Kind: raw.Kind, Metric: raw.Metric, Expected: raw.Expected, Got: raw.Got}
+	if raw.Time != nil {
+		d.Time = *raw.Time
+	}
+	return nil
+}
+
+// String describes the difference on a single line
+func (d Difference) String() string {
+	switch d.Kind {
+	case DiffType:
+		return fmt.Sprintf("result type %s, expected %s", d.Got, d.Expected)
+	case DiffMissing:
+		return fmt.Sprintf("missing series %s", d.Metric)
+	case DiffExtra:
+		return fmt.Sprintf("extra series %s", d.Metric)
+	}
+	at := ""
+	if !d.Time.IsZero() {
+		at = " at " + d.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if d.Kind == DiffGap {
+		if d.Got == "" {
+			return fmt.Sprintf("gap in %s%s, expected %s", d.Metric, at, d.Expected)
+		}
+		return fmt.Sprintf("unexpected sample in %s%s: %s", d.Metric, at, d.Got)
+	}
+	if d.Metric == nil {
+		return fmt.Sprintf("value%s is %s, expected %s", at, d.Got, d.Expected)
+	}
+	return fmt.Sprintf("value of %s%s is %s, expected %s", d.Metric, at, d.Got, d.Expected)
+}
+
+// compareResults lists the differences of got from the reference result want.
+// Series are matched on their label set, and the samples of range results on
+// their timestamp
+func compareResults(want, got ResultData, tol Tolerance) []Difference {
+	if want.Type != got.Type {
+		return []Difference{{Kind: DiffType, Expected: string(want.Type), Got: string(got.Type)}}
+	}
+	switch want.Type {
+	case ResultTypeVector:
+		return compareSeries(vectorSeries(want.Vector), vectorSeries(got.Vector), tol)
+	case ResultTypeMatrix:
+		return compareSeries(want.Matrix, got.Matrix, tol)
+	case ResultTypeScalar:
+		var w, g []Point
+		if want.Scalar != nil {
+			w = []Point{*want.Scalar}
+		}
+		if got.Scalar != nil {
+			g = []Point{*got.Scalar}
+		}
+		return comparePoints(nil, w, g, tol)
+	case ResultTypeString:
+		if want.String == nil || got.String == nil || want.String.V != got.String.V {
+			d := Difference{Kind: DiffValue}
+			if want.String != nil {
+				d.Expected = strconv.Quote(want.String.V)
+			}
+			if got.String != nil {
+				d.Got = strconv.Quote(got.String.V)
+			}
+			if want.String != nil || got.String != nil {
+				return []Difference{d}
+			}
+		}
+	}
+	return nil
+}
+
+// vectorSeries turns every sample of v into a single point series
+func vectorSeries(v Vector) Matrix {
+	m := make(Matrix, len(v))
+	for i, s := range v {
+		m[i] = Series{Metric: s.Metric, Values: []Point{s.Value}}
+	}
+	return m
+}
+
+func compareSeries(want, got Matrix, tol Tolerance) []Difference {
+	gotByKey := make(map[string]Series, len(got))
+	for _, s := range got {
+		gotByKey[labelsKey(s.Metric)] = s
+	}
+	var diffs []Difference
+	matched := make(map[string]bool, len(want))
+	for _, w := range want {
+		key := labelsKey(w.Metric)
+		matched[key] = true
+		g, ok := gotByKey[key]
+		if !ok {
+			diffs = append(diffs, Difference{Kind: DiffMissing, Metric: w.Metric})
+			continue
+		}
+		diffs = append(diffs, comparePoints(w.Metric, w.Values, g.Values, tol)...)
+	}
+	for _, g := range got {
+		if !matched[labelsKey(g.Metric)] {
+			diffs = append(diffs, Difference{Kind: DiffExtra, Metric: g.Metric})
+		}
+	}
+	return diffs
+}
+
+// comparePoints walks two series sorted by time, reporting gaps for the
+// timestamps found in only one of them
+func comparePoints(metric Labels, want, got []Point, tol Tolerance) []Difference {
+	var diffs []Difference
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case j == len(got) || (i < len(want) && want[i].T < got[j].T):
+			diffs = append(diffs, Difference{Kind: DiffGap, Metric: metric, Time: want[i].Time(), Expected: formatValue(want[i].V)})
+			i++
+		case i == len(want) || got[j].T < want[i].T:
+			diffs = append(diffs, Difference{Kind: DiffGap, Metric: metric, Time: got[j].Time(), Got: formatValue(got[j].V)})
+			j++
+		default:
+			if !tol.equal(want[i].V, got[j].V) {
+				diffs = append(diffs, Difference{Kind: DiffValue, Metric: metric, Time: want[i].Time(), Expected: formatValue(want[i].V), Got: formatValue(got[j].V)})
+			}
+			i++
+			j++
+		}
+	}
+	return diffs
+}
+
+// formatValue renders a sample value as the Prometheus API does
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTolerance(t *testing.T) {
+	cases := map[string]Tolerance{
+		"":    {},
+		"0.5": {Absolute: 0.5},
+		"1%":  {Relative: 0.01},
+	}
+	for in, want := range cases {
+		got, err := ParseTolerance(in)
+		if err != nil || got != want {
+			t.Errorf("ParseTolerance(%q) = %+v, %v, expected %+v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"-1", "abc", "x%", "-5%"} {
+		if _, err := ParseTolerance(in); err == nil {
+			t.Errorf("expected an error for %q, got nil", in)
+		}
+	}
+}
+
+func TestTolerance_Equal(t *testing.T) {
+	cases := []struct {
+		tol  Tolerance
+		a, b float64
+		want bool
+	}{
+		{Tolerance{}, 1, 1, true},
+		{Tolerance{}, 1, 1.0001, false},
+		{Tolerance{Absolute: 0.5}, 1, 1.5, true},
+		{Tolerance{Absolute: 0.5}, 1, 1.6, false},
+		{Tolerance{Relative: 0.01}, 100, 101, true},
+		{Tolerance{Relative: 0.01}, 100, 102, false},
+	}
+	for _, c := range cases {
+		if got := c.tol.equal(c.a, c.b); got != c.want {
+			t.Errorf("%+v.equal(%g, %g) = %t, expected %t", c.tol, c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestCompareResults(t *testing.T) {
+	want := ResultData{Type: ResultTypeMatrix, Matrix: Matrix{
+		{Metric: Labels{"job": "a"}, Values: []Point{{T: 1000, V: 1}, {T: 2000, V: 2}, {T: 3000, V: 3}}},
+		{Metric: Labels{"job": "b"}, Values: []Point{{T: 1000, V: 1}}},
+	}}
+	got := ResultData{Type: ResultTypeMatrix, Matrix: Matrix{
+		{Metric: Labels{"job": "a"}, Values: []Point{{T: 1000, V: 1.1}, {T: 3000, V: 5}, {T: 4000, V: 4}}},
+		{Metric: Labels{"job": "c"}, Values: []Point{{T: 1000, V: 1}}},
+	}}
+	diffs := compareResults(want, got, Tolerance{Absolute: 0.2})
+	expected := []Difference{
+		{Kind: DiffGap, Metric: Labels{"job": "a"}, Time: time.UnixMilli(2000), Expected: "2"},
+		{Kind: DiffValue, Metric: Labels{"job": "a"}, Time: time.UnixMilli(3000), Expected: "3", Got: "5"},
+		{Kind: DiffGap, Metric: Labels{"job": "a"}, Time: time.UnixMilli(4000), Got: "4"},
+		{Kind: DiffMissing, Metric: Labels{"job": "b"}},
+		{Kind: DiffExtra, Metric: Labels{"job": "c"}},
+	}
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("got %+v, expected %+v", diffs, expected)
+	}
+	if len(compareResults(want, want, Tolerance{})) != 0 {
+		t.Error("expected no differences between identical results")
+	}
+
+	diffs = compareResults(want, ResultData{Type: ResultTypeVector}, Tolerance{})
+	if len(diffs) != 1 || diffs[0].Kind != DiffType || diffs[0].String() != "result type vector, expected matrix" {
+		t.Errorf("expected a type difference, got %+v", diffs)
+	}
+}
+
+func TestDifference_JSON(t *testing.T) {
+	d := Difference{Kind: DiffValue, Metric: Labels{"job": "a"}, Time: time.Unix(1700000000, 0).UTC(), Expected: "1", Got: "2"}
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"kind":"value","metric":{"job":"a"},"time":"2023-11-14T22:13:20Z","expected":"1","got":"2"}`; string(b) != want {
+		t.Errorf("got %s, expected %s", b, want)
+	}
+	var decoded Difference
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, d) {
+		t.Errorf("got %+v after a round trip, expected %+v", decoded, d)
+	}
+	if got := (Difference{Kind: DiffMissing, Metric: Labels{"job": "a"}}).String(); got != `missing series {job="a"}` {
+		t.Errorf("unexpected description %q", got)
+	}
+}
@@ -0,0 +1,491 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cortex-client/pkg/ratelimiter"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigVersion is the latest version of the configuration file schema
+const ConfigVersion = 1
+
+// Config describes a fleet of backends. Version 1 files have a global
+// section, named backends and groups of backends. Files without a version use
+// the original form, a prometheus_backends list of URLs or backend mappings
+type Config struct {
+	Version  int                 `yaml:"version"`
+	Global   GlobalConfig        `yaml:"global"`
+	Backends []BackendConfig     `yaml:"backends"`
+	Groups   map[string][]string `yaml:"groups"`
+}
+
+// GlobalConfig holds the client-wide settings of a Config. Zero values keep
+// the client defaults
+type GlobalConfig struct {
+	Workers         int                     `yaml:"workers"`
+	Limiter         *LimiterConfig          `yaml:"limiter"`
+	Retry           *RetryPolicy            `yaml:"retry"`
+	CircuitBreaker  *BreakerConfig          `yaml:"circuit_breaker"`
+	Hedge           *HedgePolicy            `yaml:"hedge"`
+	Timeout         time.Duration           `yaml:"timeout"`
+	BackendTimeout  time.Duration           `yaml:"backend_timeout"`
+	PartialResponse PartialResponseStrategy `yaml:"partial_response"`
+}
+
+// Rate limiter types of a LimiterConfig
+const (
+	LimiterMaxConcurrency = "max-concurrency"
+	LimiterThrottle       = "throttle"
+	LimiterFixedWindow    = "fixed-window"
+)
+
+// LimiterConfig describes one of the rate limiters of the ratelimiter package
+type LimiterConfig struct {
+	// Type is max-concurrency, throttle or fixed-window, defaulting to
+	// max-concurrency
+	Type string `yaml:"type"`
+
+	// Limit is the number of tokens for max-concurrency and fixed-window
+	Limit int `yaml:"limit"`
+
+	// Throttle is the minimum time between requests for throttle
+	Throttle time.Duration `yaml:"throttle"`
+
+	// Interval is the window length for fixed-window
+	Interval time.Duration `yaml:"interval"`
+
+	// TokenResetsAfter forcefully releases tokens held for longer, zero means never
+	TokenResetsAfter time.Duration `yaml:"token_resets_after"`
+}
+
+// BackendConfig is a backend entry of a Config
+type BackendConfig struct {
+	Name            string         `yaml:"name"`
+	URL             string         `yaml:"url"`
+	Labels          Labels         `yaml:"labels"`
+	Tenant          string         `yaml:"tenant"`
+	Timeout         time.Duration  `yaml:"timeout"`
+	Weight          int            `yaml:"weight"`
+	ReadyPath       string         `yaml:"ready_path"`
+	TimeWindow      TimeWindow     `yaml:"time_window"`
+	RateLimit       *LimiterConfig `yaml:"rate_limit"`
+	BasicAuth       *BasicAuth     `yaml:"basic_auth"`
+	BearerToken     string         `yaml:"bearer_token"`
+	BearerTokenFile string         `yaml:"bearer_token_file"`
+	BearerTokenEnv  string         `yaml:"bearer_token_env"`
+	OAuth2          *OAuth2        `yaml:"oauth2"`
+	SigV4           *SigV4         `yaml:"sigv4"`
+	TLS             *TLSConfig     `yaml:"tls_config"`
+}
+
+// ConfigError is a mistake in a configuration file, located by line
+type ConfigError struct {
+	Line int
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// LoadConfig reads and validates a configuration file. Relative file paths in
+// backend credentials are resolved against the file's directory
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	for i := range cfg.Backends {
+		cfg.Backends[i].resolvePaths(dir)
+	}
+	return cfg, nil
+}
+
+// ParseConfig decodes and validates a configuration document. Unknown fields
+// are rejected and every validation error is reported with its line
+func ParseConfig(data []byte) (*Config, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		// an empty file is an empty list of backends in the original form
+		return &Config{}, nil
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return nil, &ConfigError{Line: doc.Line, Err: errors.New("configuration must be a mapping")}
+	}
+	if lookup(doc, "version") == nil {
+		return parseLegacyConfig(doc)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	v := &configValidator{}
+	cfg.validate(v, doc)
+	if err := errors.Join(v.errs...); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// parseLegacyConfig reads the original prometheus_backends form, whose
+// entries are URLs or backend mappings
+func parseLegacyConfig(doc *yaml.Node) (*Config, error) {
+	list := lookup(doc, "prometheus_backends")
+	if list == nil {
+		return nil, &ConfigError{Line: doc.Line, Err: errors.New("configuration needs a version or a prometheus_backends list")}
+	}
+	if list.Kind != yaml.SequenceNode {
+		return nil, &ConfigError{Line: list.Line, Err: errors.New("prometheus_backends must be a list")}
+	}
+	cfg := &Config{}
+	v := &configValidator{}
+	for _, n := range list.Content {
+		var b BackendConfig
+		if n.Kind == yaml.ScalarNode {
+			b.URL = n.Value
+		} else {
+			checkKnownFields(v, n, reflect.TypeOf(b))
+			if err := n.Decode(&b); err != nil {
+				v.add(n, err)
+				continue
+			}
+		}
+		if b.Name != "" && slices.ContainsFunc(cfg.Backends, func(other BackendConfig) bool { return other.Name == b.Name }) {
+			v.addf(nodeOr(lookup(n, "name"), n), "duplicate backend name %q", b.Name)
+		}
+		b.validate(v, n)
+		cfg.Backends = append(cfg.Backends, b)
+	}
+	if err := errors.Join(v.errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// configValidator collects the validation errors of a document
+type configValidator struct {
+	errs []error
+}
+
+func (v *configValidator) add(n *yaml.Node, err error) {
+	v.errs = append(v.errs, &ConfigError{Line: n.Line, Err: err})
+}
+
+func (v *configValidator) addf(n *yaml.Node, format string, args ...any) {
+	v.add(n, fmt.Errorf(format, args...))
+}
+
+var labelNameRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func (c *Config) validate(v *configValidator, doc *yaml.Node) {
+	if c.Version != ConfigVersion {
+		v.addf(lookup(doc, "version"), "unsupported config version %d, expected %d", c.Version, ConfigVersion)
+		return
+	}
+
+	global := nodeOr(lookup(doc, "global"), doc)
+	if c.Global.Workers < 0 {
+		v.addf(nodeOr(lookup(global, "workers"), global), "workers must not be negative")
+	}
+	if c.Global.Limiter != nil {
+		c.Global.Limiter.validate(v, nodeOr(lookup(global, "limiter"), global))
+	}
+	if c.Global.Retry != nil {
+		if err := c.Global.Retry.validate(); err != nil {
+			v.add(nodeOr(lookup(global, "retry"), global), err)
+		}
+	}
+	if c.Global.CircuitBreaker != nil {
+		if err := c.Global.CircuitBreaker.validate(); err != nil {
+			v.add(nodeOr(lookup(global, "circuit_breaker"), global), err)
+		}
+	}
+	if c.Global.Hedge != nil {
+		if err := c.Global.Hedge.validate(); err != nil {
+			v.add(nodeOr(lookup(global, "hedge"), global), err)
+		}
+	}
+	if c.Global.Timeout < 0 {
+		v.addf(nodeOr(lookup(global, "timeout"), global), "timeout must not be negative")
+	}
+	if c.Global.BackendTimeout < 0 {
+		v.addf(nodeOr(lookup(global, "backend_timeout"), global), "backend_timeout must not be negative")
+	}
+	if c.Global.PartialResponse != "" {
+		if _, err := ParsePartialResponseStrategy(string(c.Global.PartialResponse)); err != nil {
+			v.add(nodeOr(lookup(global, "partial_response"), global), err)
+		}
+	}
+
+	list := nodeOr(lookup(doc, "backends"), doc)
+	if len(c.Backends) == 0 {
+		v.addf(list, "at least one backend is required")
+	}
+	names := make(map[string]bool, len(c.Backends))
+	for i, b := range c.Backends {
+		n := list.Content[i]
+		if b.Name == "" {
+			v.addf(n, "backend needs a name")
+		} else if names[b.Name] {
+			v.addf(nodeOr(lookup(n, "name"), n), "duplicate backend name %q", b.Name)
+		}
+		names[b.Name] = true
+		b.validate(v, n)
+	}
+
+	groups := nodeOr(lookup(doc, "groups"), doc)
+	for _, name := range slices.Sorted(maps.Keys(c.Groups)) {
+		members := c.Groups[name]
+		n := groups
+		if key := lookupKey(groups, name); key != nil {
+			n = key
+		}
+		switch {
+		case names[name]:
+			v.addf(n, "group %q has the name of a backend", name)
+		case len(members) == 0:
+			v.addf(n, "group %q has no backends", name)
+		}
+		memberNodes := nodeOr(lookup(groups, name), n)
+		for i, member := range members {
+			if !names[member] {
+				m := memberNodes
+				if i < len(memberNodes.Content) {
+					m = memberNodes.Content[i]
+				}
+				v.addf(m, "group %q refers to unknown backend %q", name, member)
+			}
+		}
+	}
+}
+
+func (b BackendConfig) validate(v *configValidator, n *yaml.Node) {
+	field := func(key string) *yaml.Node {
+		return nodeOr(lookup(n, key), n)
+	}
+	reported := len(v.errs)
+	if b.URL == "" {
+		v.addf(n, "backend %s needs a url", b.Name)
+	} else if u, err := url.Parse(b.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf(field("url"), "backend url %q must be an absolute http or https URL", b.URL)
+	}
+	for name := range b.Labels {
+		if !labelNameRE.MatchString(name) {
+			v.addf(field("labels"), "invalid label name %q", name)
+		}
+	}
+	if b.Tenant != "" {
+		if err := ValidateTenant(b.Tenant); err != nil {
+			v.add(field("tenant"), err)
+		}
+	}
+	if b.Timeout < 0 {
+		v.addf(field("timeout"), "timeout must not be negative")
+	}
+	if b.Weight < 0 {
+		v.addf(field("weight"), "weight must not be negative")
+	}
+	if err := b.TimeWindow.validate(); err != nil {
+		v.add(field("time_window"), err)
+	}
+	if b.ReadyPath != "" && !strings.HasPrefix(b.ReadyPath, "/") {
+		v.addf(field("ready_path"), "ready path %q must start with /", b.ReadyPath)
+	}
+	if b.RateLimit != nil {
+		b.RateLimit.validate(v, field("rate_limit"))
+	}
+	// the remaining checks are shared with backends configured in code, and
+	// would repeat the errors found above
+	if b.URL != "" && len(v.errs) == reported {
+		if err := b.backend().validate(); err != nil {
+			v.add(n, err)
+		}
+	}
+}
+
+func (l *LimiterConfig) validate(v *configValidator, n *yaml.Node) {
+	switch l.Type {
+	case "", LimiterMaxConcurrency:
+		if l.Limit <= 0 {
+			v.addf(n, "%s rate limiter needs a limit greater than zero", LimiterMaxConcurrency)
+		}
+	case LimiterThrottle:
+		if l.Throttle <= 0 {
+			v.addf(n, "%s rate limiter needs a throttle greater than zero", LimiterThrottle)
+		}
+	case LimiterFixedWindow:
+		if l.Limit <= 0 || l.Interval <= 0 {
+			v.addf(n, "%s rate limiter needs a limit and an interval greater than zero", LimiterFixedWindow)
+		}
+	default:
+		v.addf(nodeOr(lookup(n, "type"), n), "unknown rate limiter type %q, expected %s, %s or %s", l.Type, LimiterMaxConcurrency, LimiterThrottle, LimiterFixedWindow)
+	}
+	if l.TokenResetsAfter < 0 {
+		v.addf(n, "token_resets_after must not be negative")
+	}
+}
+
+// build creates the rate limiter
+func (l *LimiterConfig) build() (ratelimiter.RateLimiter, error) {
+	conf := &ratelimiter.Config{
+		Limit:            l.Limit,
+		Throttle:         l.Throttle,
+		FixedInterval:    l.Interval,
+		TokenResetsAfter: l.TokenResetsAfter,
+	}
+	switch l.Type {
+	case "", LimiterMaxConcurrency:
+		return ratelimiter.NewMaxConcurrencyRateLimiter(conf)
+	case LimiterThrottle:
+		return ratelimiter.NewThrottleRateLimiter(conf)
+	case LimiterFixedWindow:
+		return ratelimiter.NewFixedWindowRateLimiter(conf)
+	}
+	return nil, fmt.Errorf("unknown rate limiter type %q", l.Type)
+}
+
+// backend converts the entry into a Backend, without its rate limiter
+func (b BackendConfig) backend() Backend {
+	backend := Backend{
+		Name:       b.Name,
+		URL:        b.URL,
+		Labels:     b.Labels,
+		Tenant:     b.Tenant,
+		Timeout:    b.Timeout,
+		Weight:     b.Weight,
+		ReadyPath:  b.ReadyPath,
+		TimeWindow: b.TimeWindow,
+		BasicAuth:  b.BasicAuth,
+		OAuth2:     b.OAuth2,
+		SigV4:      b.SigV4,
+		TLS:        b.TLS,
+	}
+	if b.BearerToken != "" || b.BearerTokenFile != "" || b.BearerTokenEnv != "" {
+		backend.BearerToken = &BearerToken{Token: b.BearerToken, File: b.BearerTokenFile, Env: b.BearerTokenEnv}
+	}
+	return backend
+}
+
+// Backend converts the entry into a Backend, creating its rate limiter
+func (b BackendConfig) Backend() (Backend, error) {
+	backend := b.backend()
+	if b.RateLimit != nil {
+		limiter, err := b.RateLimit.build()
+		if err != nil {
+			return Backend{}, fmt.Errorf("backend %s: %w", backend, err)
+		}
+		backend.Limiter = limiter
+	}
+	return backend, nil
+}
+
+// resolvePaths makes the relative file paths of the entry relative to dir
+func (b *BackendConfig) resolvePaths(dir string) {
+	resolve := func(p *string) {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	resolve(&b.BearerTokenFile)
+	if b.BasicAuth != nil {
+		resolve(&b.BasicAuth.PasswordFile)
+	}
+	if b.OAuth2 != nil {
+		resolve(&b.OAuth2.ClientSecretFile)
+	}
+	if b.TLS != nil {
+		resolve(&b.TLS.CAFile)
+		resolve(&b.TLS.CertFile)
+		resolve(&b.TLS.KeyFile)
+	}
+}
+
+// checkKnownFields reports the keys of mapping n that are not fields of the
+// struct type t, like the strict decoder does for versioned files
+func checkKnownFields(v *configValidator, n *yaml.Node, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || n.Kind != yaml.MappingNode {
+		return
+	}
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key := n.Content[i]
+		field, ok := fields[key.Value]
+		if !ok {
+			v.addf(key, "field %s not found in type %s", key.Value, t)
+			continue
+		}
+		checkKnownFields(v, n.Content[i+1], field)
+	}
+}
+
+// lookupKey returns the key node of key in a mapping node, or nil
+func lookupKey(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i]
+		}
+	}
+	return nil
+}
+
+// lookup returns the value node of key in a mapping node, or nil
+func lookup(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// nodeOr returns n, or fallback when n is missing, so that errors about
+// missing fields point at the enclosing node
+func nodeOr(n, fallback *yaml.Node) *yaml.Node {
+	if n == nil {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,241 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`version: 1
+global:
+  workers: 3
+  timeout: 1m
+  backend_timeout: 10s
+  partial_response: strict
+  limiter: {type: throttle, throttle: 50ms}
+  retry: {max_attempts: 2, initial_backoff: 1s}
+backends:
+  - name: eu
+    url: http://eu:9090
+    labels: {region: eu}
+    weight: 2
+    timeout: 5s
+    rate_limit: {limit: 4}
+  - name: us
+    url: http://us:9090
+    tenant: team-a
+    ready_path: /ready
+    bearer_token_env: US_TOKEN
+    time_window: {min_age: 2h}
+groups:
+  all: [eu, us]
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantGlobal := GlobalConfig{
+		Workers:         3,
+		Limiter:         &LimiterConfig{Type: LimiterThrottle, Throttle: 50 * time.Millisecond},
+		Retry:           &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Second},
+		Timeout:         time.Minute,
+		BackendTimeout:  10 * time.Second,
+		PartialResponse: PartialResponseStrict,
+	}
+	if !reflect.DeepEqual(cfg.Global, wantGlobal) {
+		t.Errorf("got global %+v, want %+v", cfg.Global, wantGlobal)
+	}
+	if len(cfg.Backends) != 2 {
+		t.Fatalf("expected two backends, got %+v", cfg.Backends)
+	}
+	eu, err := cfg.Backends[0].Backend()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if eu.Labels["region"] != "eu" || eu.Weight != 2 || eu.Timeout != 5*time.Second || eu.Limiter == nil {
+		t.Errorf("unexpected backend %+v", eu)
+	}
+	us := cfg.Backends[1].backend()
+	if us.Tenant != "team-a" || us.BearerToken == nil || us.BearerToken.Env != "US_TOKEN" || us.TimeWindow != (TimeWindow{MinAge: 2 * time.Hour}) || us.ReadyPath != "/ready" {
+		t.Errorf("unexpected backend %+v", us)
+	}
+	if !reflect.DeepEqual(cfg.Groups, map[string][]string{"all": {"eu", "us"}}) {
+		t.Errorf("unexpected groups %+v", cfg.Groups)
+	}
+}
+
+func TestParseConfig_Legacy(t *testing.T) {
+	cfg, err := ParseConfig([]byte("prometheus_backends:\n  - http://a:9090\n  - url: http://b:9090\n    name: b\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []BackendConfig{{URL: "http://a:9090"}, {Name: "b", URL: "http://b:9090"}}
+	if !reflect.DeepEqual(cfg.Backends, want) {
+		t.Errorf("got %+v, want %+v", cfg.Backends, want)
+	}
+
+	cfg, err = ParseConfig(nil)
+	if err != nil || len(cfg.Backends) != 0 {
+		t.Errorf("expected an empty file to have no backends, got %+v, %v", cfg, err)
+	}
+}
+
+func TestParseConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		errors []string
+	}{
+		{
+			name:   "unknown field",
+			config: "version: 1\nbackends:\n  - name: a\n    url: http://a\n    tenat: x\n",
+			errors: []string{"line 5: field tenat not found"},
+		},
+		{
+			name:   "unknown legacy field",
+			config: "prometheus_backends:\n  - url: http://a\n    basic_auth:\n      user: x\n",
+			errors: []string{"line 4: field user not found"},
+		},
+		{
+			name:   "unsupported version",
+			config: "version: 2\nbackends: []\n",
+			errors: []string{"line 1: unsupported config version 2"},
+		},
+		{
+			name: "backends",
+			config: "version: 1\n" +
+				"backends:\n" +
+				"  - url: http://a\n" +
+				"  - name: b\n" +
+				"    url: ftp://b\n" +
+				"  - name: b\n" +
+				"    url: http://c\n" +
+				"    tenant: team/a\n" +
+				"    labels: {0bad: x}\n" +
+				"    weight: -1\n" +
+				"    time_window: {max_age: 1h, min_age: 2h}\n" +
+				"    ready_path: ready\n",
+			errors: []string{
+				"line 3: backend needs a name",
+				`line 5: backend url "ftp://b" must be an absolute http or https URL`,
+				`line 6: duplicate backend name "b"`,
+				"line 8: tenant",
+				`line 9: invalid label name "0bad"`,
+				"line 10: weight must not be negative",
+				"line 11: time window max_age must be greater than min_age",
+				`line 12: ready path "ready" must start with /`,
+			},
+		},
+		{
+			name: "globals",
+			config: "version: 1\n" +
+				"global:\n" +
+				"  workers: -1\n" +
+				"  partial_response: sometimes\n" +
+				"  limiter:\n" +
+				"    type: leaky\n" +
+				"backends:\n" +
+				"  - name: a\n" +
+				"    url: http://a\n" +
+				"    rate_limit: {type: fixed-window, limit: 5}\n",
+			errors: []string{
+				"line 3: workers must not be negative",
+				"line 4: unknown partial response strategy",
+				`line 6: unknown rate limiter type "leaky"`,
+				"line 10: fixed-window rate limiter needs a limit and an interval",
+			},
+		},
+		{
+			name: "groups",
+			config: "version: 1\n" +
+				"backends:\n" +
+				"  - name: a\n" +
+				"    url: http://a\n" +
+				"groups:\n" +
+				"  a: [a]\n" +
+				"  b: [a, c]\n",
+			errors: []string{
+				`line 6: group "a" has the name of a backend`,
+				`line 7: group "b" refers to unknown backend "c"`,
+			},
+		},
+		{
+			name:   "credentials",
+			config: "version: 1\nbackends:\n  - name: a\n    url: http://a\n    bearer_token: x\n    basic_auth: {username: u}\n",
+			errors: []string{"line 3: backend a: basic auth, bearer token"},
+		},
+	}
+	for _, tc := range tests {
+		_, err := ParseConfig([]byte(tc.config))
+		if err == nil {
+			t.Errorf("%s: expected errors, got nil", tc.name)
+			continue
+		}
+		for _, want := range tc.errors {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: expected %q in error, got: %v", tc.name, want, err)
+			}
+		}
+	}
+
+	_, err := ParseConfig([]byte("version: 1\nbackends:\n  - url: http://a\n"))
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Line != 3 {
+		t.Errorf("expected a ConfigError at line 3, got %v", err)
+	}
+}
+
+func TestWithConfig(t *testing.T) {
+	var order []string
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, name)
+			mockPrometheusHandler(t)(w, r)
+		})
+	}
+	low := httptest.NewServer(handler("low"))
+	defer low.Close()
+	high := httptest.NewServer(handler("high"))
+	defer high.Close()
+	other := httptest.NewServer(handler("other"))
+	defer other.Close()
+
+	cfg, err := ParseConfig([]byte("version: 1\n" +
+		"global: {workers: 1, backend_timeout: 3s}\n" +
+		"backends:\n" +
+		"  - {name: low, url: " + low.URL + "}\n" +
+		"  - {name: high, url: " + high.URL + ", weight: 10}\n" +
+		"  - {name: other, url: " + other.URL + "}\n" +
+		"groups:\n" +
+		"  pair: [low, high]\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := New(WithConfig(cfg))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.workers != 1 || c.backendTimeout != 3*time.Second {
+		t.Errorf("expected the global settings to apply, got %d workers and %s backend timeout", c.workers, c.backendTimeout)
+	}
+
+	merged, err := c.Query(context.Background(), QueryData{Query: "up", Backends: []string{"pair"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(order, []string{"high", "low"}) {
+		t.Errorf("expected the group queried by weight, got %v", order)
+	}
+	if len(merged.Backends) != 2 || merged.Backends[0].Backend != "high" {
+		t.Errorf("unexpected backend statuses %+v", merged.Backends)
+	}
+
+	if _, err := New(WithBackends(Backend{Name: "a", URL: "http://a"}), WithGroup("g", "b")); err == nil {
+		t.Error("expected error for a group with an unknown backend, got nil")
+	}
+}
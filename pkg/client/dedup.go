@@ -0,0 +1,100 @@
+package client
+
+import (
+	"sort"
+)
+
+// DefaultReplicaLabel is the replica label used by Cortex HA tracking
+const DefaultReplicaLabel = "__replica__"
+
+func (s MergeStrategy) replicaLabel() string {
+	if s.ReplicaLabel == "" {
+		return DefaultReplicaLabel
+	}
+	return s.ReplicaLabel
+}
+
+// dedupGroup collapses the copies of a series returned by HA replicas. The
+// healthiest copy, the one with the most samples and then the most recent one,
+// is kept and gaps in its samples are filled from the other replicas
+func dedupGroup(group []seriesEntry) seriesEntry {
+	ranked := make([]seriesEntry, len(group))
+	copy(ranked, group)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if a, b := len(ranked[i].series.Values), len(ranked[j].series.Values); a != b {
+			return a > b
+		}
+		return ranked[i].latest > ranked[j].latest
+	})
+
+	primary := ranked[0]
+	if len(ranked) == 1 || len(primary.series.Values) == 0 {
+		return primary
+	}
+
+	others := make([][]Point, 0, len(ranked)-1)
+	for _, e := range ranked[1:] {
+		others = append(others, e.series.Values)
+	}
+	primary.series.Values = stitchPoints(primary.series.Values, others...)
+	if n := len(primary.series.Values); n > 0 {
+		primary.latest = primary.series.Values[n-1].T
+	}
+	return primary
+}
+
+// stitchPoints fills the gaps in primary with samples from the other replicas.
+// A replica sample is only used when no existing sample lies within one scrape
+// interval of it, so replicas scraping at different offsets are not interleaved
+func stitchPoints(primary []Point, others ...[]Point) []Point {
+	interval := sampleInterval(append([][]Point{primary}, others...)...)
+	out := make([]Point, len(primary))
+	copy(out, primary)
+	for _, points := range others {
+		for _, p := range points {
+			i := sort.Search(len(out), func(i int) bool { return out[i].T >= p.T })
+			if i < len(out) && out[i].T-p.T < interval {
+				continue
+			}
+			if i > 0 && p.T-out[i-1].T < interval {
+				continue
+			}
+			out = append(out, Point{})
+			copy(out[i+1:], out[i:])
+			out[i] = p
+		}
+	}
+	return out
+}
+
+// sampleInterval estimates the scrape or step interval as the smallest
+// distance between consecutive samples, since gaps only ever widen it. It
+// falls back to one millisecond when no series has two samples
+func sampleInterval(series ...[]Point) int64 {
+	var interval int64
+	for _, points := range series {
+		for i := 1; i < len(points); i++ {
+			if d := points[i].T - points[i-1].T; d > 0 && (interval == 0 || d < interval) {
+				interval = d
+			}
+		}
+	}
+	if interval == 0 {
+		return 1
+	}
+	return interval
+}
+
+// withoutLabel returns a copy of l without name
+func withoutLabel(l Labels, name string) Labels {
+	if _, ok := l[name]; !ok {
+		return l
+	}
+	out := make(Labels, len(l))
+	for k, v := range l {
+		if k != name {
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeResults_DedupReplicas(t *testing.T) {
+	results := []backendResult{
+		{Backend: "a", Response: &PrometheusResponse{Data: ResultData{Type: ResultTypeMatrix, Matrix: Matrix{
+			{Metric: Labels{"job": "node", "replica": "a"}, Values: []Point{{T: 0, V: 1}, {T: 30, V: 3}}},
+		}}}},
+		{Backend: "b", Response: &PrometheusResponse{Data: ResultData{Type: ResultTypeMatrix, Matrix: Matrix{
+			{Metric: Labels{"job": "node", "replica": "b"}, Values: []Point{{T: 0, V: 10}, {T: 15, V: 20}, {T: 45, V: 40}}},
+		}}}},
+	}
+	merged, err := mergeResults(results, ResultTypeMatrix, MergeStrategy{Dedup: true, ReplicaLabel: "replica"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Matrix{{
+		Metric: Labels{"job": "node"},
+		// replica b has the most samples so it is primary; its gap at 30 is filled from a
+		Values: []Point{{T: 0, V: 10}, {T: 15, V: 20}, {T: 30, V: 3}, {T: 45, V: 40}},
+	}}
+	if !reflect.DeepEqual(merged.Matrix, want) {
+		t.Errorf("got %+v, want %+v", merged.Matrix, want)
+	}
+}
+
+func TestMergeResults_DedupVectorWithoutReplicaLabel(t *testing.T) {
+	results := []backendResult{
+		vectorResult("a", Sample{Metric: Labels{"job": "node"}, Value: Point{T: 1000, V: 1}}),
+		vectorResult("b", Sample{Metric: Labels{"job": "node"}, Value: Point{T: 1000, V: 2}}),
+	}
+	merged, err := mergeResults(results, ResultTypeVector, MergeStrategy{Dedup: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Vector{{Metric: Labels{"job": "node"}, Value: Point{T: 1000, V: 1}}}
+	if !reflect.DeepEqual(merged.Vector, want) {
+		t.Errorf("got %+v, want %+v", merged.Vector, want)
+	}
+}
+
+func TestStitchPoints_UnalignedReplicas(t *testing.T) {
+	primary := []Point{{T: 0}, {T: 15}, {T: 60}}
+	other := []Point{{T: 7}, {T: 22}, {T: 37}, {T: 52}}
+	got := stitchPoints(primary, other)
+	want := []Point{{T: 0}, {T: 15}, {T: 37}, {T: 60}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DiffReport describes how the results of a query differ between backends
+type DiffReport struct {
+	// Reference is the first backend that answered, which the others are
+	// compared with
+	Reference string `json:"reference"`
+
+	// Matching are the backends whose result matches the reference
+	Matching []string `json:"matching"`
+
+	// Disagreements lists how the results of the other backends differ
+	Disagreements []Disagreement `json:"disagreements,omitempty"`
+
+	Warnings []string        `json:"warnings,omitempty"`
+	Backends []BackendStatus `json:"backends"`
+
+	// EvaluationTime is the time every backend evaluated an instant query at
+	EvaluationTime *time.Time `json:"evaluationTime,omitempty"`
+}
+
+// Differ reports whether any backend disagrees with the reference
+func (r *DiffReport) Differ() bool {
+	return len(r.Disagreements) > 0
+}
+
+// Diff sends data.Query to every backend, at least two of them, and compares
+// their results with the one of the first backend that answered. Series are
+// matched on their label set, values within data.Tolerance are equal, and
+// samples a range query returned on only one side are reported as gaps.
+// Backends that cannot hold data for all of the query are left out, see
+// Explain. data.Mode and data.Merge are ignored
+func (c *Client) Diff(ctx context.Context, data QueryData) (*DiffReport, error) {
+	data = c.withDefaults(data)
+	if len(data.Tenants) > 0 {
+		return nil, errors.New("diff compares backends and cannot fan out to tenants")
+	}
+	data = pinTimes(data)
+	backends, _ := route(c.queryBackends(data), data, false)
+	if len(backends) < 2 {
+		return nil, fmt.Errorf("diff needs at least two backends holding data for the query, got %d", len(backends))
+	}
+
+	if data.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, data.Timeout)
+		defer cancel()
+	}
+
+	succeeded, statuses, warnings, err := settle(fanOut(ctx, c, backends, data.BackendTimeout, c.queryCall(data)), data.PartialResponse)
+	if err != nil {
+		return nil, err
+	}
+	if len(succeeded) < 2 {
+		return nil, fmt.Errorf("only backend %s answered, at least two are needed to compare: %w", succeeded[0].Backend, &PartialResponseError{Backends: statuses})
+	}
+
+	ref := succeeded[0]
+	report := &DiffReport{Reference: ref.Backend, Matching: []string{}, Warnings: warnings, Backends: statuses}
+	if !data.IsRange() {
+		report.EvaluationTime = &data.Time
+	}
+	for _, r := range succeeded[1:] {
+		diffs := compareResults(ref.Response.Data, r.Response.Data, data.Tolerance)
+		if len(diffs) == 0 {
+			report.Matching = append(report.Matching, r.Backend)
+			continue
+		}
+		report.Disagreements = append(report.Disagreements, Disagreement{Backend: r.Backend, Reference: ref.Backend, Differences: diffs})
+	}
+	return report, nil
+}
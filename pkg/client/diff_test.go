@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// matrixServer answers range queries with body as the result
+func matrixServer(t *testing.T, result string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":` + result + `}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+}
+
+func TestClient_Diff(t *testing.T) {
+	full := `[{"metric":{"job":"a"},"values":[[1700000000,"1"],[1700000015,"2"]]},{"metric":{"job":"b"},"values":[[1700000000,"1"]]}]`
+	a := matrixServer(t, full)
+	defer a.Close()
+	same := matrixServer(t, full)
+	defer same.Close()
+	gappy := matrixServer(t, `[{"metric":{"job":"a"},"values":[[1700000000,"1.5"]]}]`)
+	defer gappy.Close()
+
+	c, err := New(WithBackends(Backend{Name: "a", URL: a.URL}, Backend{Name: "same", URL: same.URL}, Backend{Name: "gappy", URL: gappy.URL}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report, err := c.Diff(context.Background(), QueryData{
+		Query: "up",
+		Start: time.Unix(1700000000, 0),
+		End:   time.Unix(1700000015, 0),
+		Step:  15 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Reference != "a" || len(report.Matching) != 1 || report.Matching[0] != "same" || !report.Differ() {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(report.Disagreements) != 1 {
+		t.Fatalf("expected gappy to disagree, got %+v", report.Disagreements)
+	}
+	var kinds []DiffKind
+	for _, d := range report.Disagreements[0].Differences {
+		kinds = append(kinds, d.Kind)
+	}
+	if len(kinds) != 3 || kinds[0] != DiffValue || kinds[1] != DiffGap || kinds[2] != DiffMissing {
+		t.Errorf("expected a value difference, a gap and a missing series, got %v", kinds)
+	}
+
+	report, err = c.Diff(context.Background(), QueryData{
+		Query:    "up",
+		Backends: []string{gappy.URL, gappy.URL},
+		Start:    time.Unix(1700000000, 0),
+		End:      time.Unix(1700000015, 0),
+		Step:     15 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Differ() {
+		t.Errorf("expected identical backends to match, got %+v", report.Disagreements)
+	}
+}
+
+func TestClient_DiffErrors(t *testing.T) {
+	a := matrixServer(t, `[]`)
+	defer a.Close()
+	c, err := New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.Diff(context.Background(), QueryData{Query: "up", Backends: []string{a.URL}}); err == nil {
+		t.Error("expected an error for a single backend, got nil")
+	}
+
+	// the instant query endpoint is not served, so only one backend answers
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	_, err = c.Diff(context.Background(), QueryData{Query: "up", Backends: []string{a.URL, failing.URL}, Start: time.Unix(1700000000, 0)})
+	var partialErr *PartialResponseError
+	if !errors.As(err, &partialErr) {
+		t.Errorf("expected a partial response error, got %v", err)
+	}
+}
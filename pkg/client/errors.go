@@ -0,0 +1,75 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Errors used throughout the codebase
+var (
+	ErrNoBackendSucceeded = errors.New("no backend returned a successful response")
+)
+
+// APIError is returned when a backend answers with a non-2xx status or a
+// Prometheus error document
+type APIError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+
+	// Type is the Prometheus errorType, empty when the body was not JSON
+	Type string
+
+	// Msg is the Prometheus error message or the start of the response body
+	Msg string
+}
+
+func (e *APIError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Msg)
+	}
+	return fmt.Sprintf("HTTP %d %s: %s", e.StatusCode, e.Type, e.Msg)
+}
+
+// newAPIError builds an APIError from a response that is not a success
+func newAPIError(statusCode int, body []byte, decoded *apiResponse) *APIError {
+	if decoded != nil && decoded.Status == "error" {
+		return &APIError{StatusCode: statusCode, Type: decoded.ErrorType, Msg: decoded.Error}
+	}
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > 256 {
+		msg = msg[:256] + "..."
+	}
+	if msg == "" {
+		msg = http.StatusText(statusCode)
+	}
+	return &APIError{StatusCode: statusCode, Msg: msg}
+}
+
+// PartialResponseError is returned when backends failed and the partial
+// response strategy does not allow returning what succeeded
+type PartialResponseError struct {
+	// Backends holds the outcome of every backend, successful or not
+	Backends []BackendStatus
+}
+
+func (e *PartialResponseError) Error() string {
+	var failed []string
+	for _, b := range e.Backends {
+		if b.Status != BackendStatusSuccess {
+			failed = append(failed, fmt.Sprintf("%s: %s", b.Backend, b.Error))
+		}
+	}
+	return fmt.Sprintf("%d of %d backends failed: %s", len(failed), len(e.Backends), strings.Join(failed, "; "))
+}
+
+// Unwrap returns ErrNoBackendSucceeded when every backend failed
+func (e *PartialResponseError) Unwrap() error {
+	for _, b := range e.Backends {
+		if b.Status == BackendStatusSuccess {
+			return nil
+		}
+	}
+	return ErrNoBackendSucceeded
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultTraceIDLabels are the exemplar labels that commonly carry a trace ID,
+// in the order they are looked up
+var DefaultTraceIDLabels = []string{"trace_id", "traceID", "traceId"}
+
+// Exemplar is a sample linked to external data such as a trace
+type Exemplar struct {
+	Labels Labels
+	T      int64
+	V      float64
+}
+
+type exemplarJSON struct {
+	Labels    Labels          `json:"labels"`
+	Value     string          `json:"value"`
+	Timestamp json.RawMessage `json:"timestamp"`
+}
+
+// Time returns the exemplar timestamp as a time.Time
+func (e Exemplar) Time() time.Time {
+	return time.UnixMilli(e.T)
+}
+
+// TraceID returns the value of the first of names present on the exemplar,
+// looking up DefaultTraceIDLabels when no names are given
+func (e Exemplar) TraceID(names ...string) string {
+	if len(names) == 0 {
+		names = DefaultTraceIDLabels
+	}
+	for _, name := range names {
+		if id, ok := e.Labels[name]; ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// MarshalJSON encodes the exemplar as Prometheus does, with the value as a
+// string and the timestamp as fractional unix seconds
+func (e Exemplar) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exemplarJSON{
+		Labels:    e.Labels,
+		Value:     strconv.FormatFloat(e.V, 'f', -1, 64),
+		Timestamp: json.RawMessage(formatTimestamp(e.T)),
+	})
+}
+
+// UnmarshalJSON decodes an exemplar from the Prometheus API
+func (e *Exemplar) UnmarshalJSON(b []byte) error {
+	var raw exemplarJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	secs, err := strconv.ParseFloat(string(raw.Timestamp), 64)
+	if err != nil {
+		return fmt.Errorf("invalid exemplar timestamp %s: %w", raw.Timestamp, err)
+	}
+	v, err := strconv.ParseFloat(raw.Value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid exemplar value %q: %w", raw.Value, err)
+	}
+	*e = Exemplar{Labels: raw.Labels, T: int64(math.Round(secs * 1e3)), V: v}
+	return nil
+}
+
+// ExemplarSeries holds the exemplars recorded for one series
+type ExemplarSeries struct {
+	SeriesLabels Labels     `json:"seriesLabels"`
+	Exemplars    []Exemplar `json:"exemplars"`
+}
+
+// ExemplarsResponse is the union of the exemplars found on every backend
+type ExemplarsResponse struct {
+	Status   string           `json:"status"`
+	Data     []ExemplarSeries `json:"data"`
+	Warnings []string         `json:"warnings,omitempty"`
+	Backends []BackendStatus  `json:"backends,omitempty"`
+}
+
+// Exemplars lists the exemplars of the series selected by query within the
+// time range of q on every backend. Series are merged by label set and
+// exemplars reported by several backends, such as HA replicas, are kept once
+func (c *Client) Exemplars(ctx context.Context, query string, q MetadataQuery) (*ExemplarsResponse, error) {
+	params := url.Values{"query": {query}}
+	outcomes, statuses, warnings, err := fanOutMetadata[[]ExemplarSeries](ctx, c, q, "/api/v1/query_exemplars", params)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ExemplarsResponse{Status: "success", Data: []ExemplarSeries{}, Warnings: warnings, Backends: statuses}
+	index := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, o := range outcomes {
+		for _, s := range o.Response {
+			key := labelsKey(s.SeriesLabels)
+			i, ok := index[key]
+			if !ok {
+				i = len(resp.Data)
+				index[key] = i
+				resp.Data = append(resp.Data, ExemplarSeries{SeriesLabels: s.SeriesLabels, Exemplars: []Exemplar{}})
+			}
+			for _, e := range s.Exemplars {
+				k := fmt.Sprintf("%s%d\xff%g\xff%s", key, e.T, e.V, labelsKey(e.Labels))
+				if !seen[k] {
+					seen[k] = true
+					resp.Data[i].Exemplars = append(resp.Data[i].Exemplars, e)
+				}
+			}
+		}
+	}
+	sort.Slice(resp.Data, func(i, j int) bool {
+		return resp.Data[i].SeriesLabels.String() < resp.Data[j].SeriesLabels.String()
+	})
+	for _, s := range resp.Data {
+		sort.SliceStable(s.Exemplars, func(i, j int) bool { return s.Exemplars[i].T < s.Exemplars[j].T })
+	}
+	return resp, nil
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExemplarJSONRoundTrip(t *testing.T) {
+	in := `{"labels":{"trace_id":"abc"},"value":"6.5","timestamp":1600096945.479}`
+	var e Exemplar
+	if err := json.Unmarshal([]byte(in), &e); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.T != 1600096945479 || e.V != 6.5 || e.TraceID() != "abc" {
+		t.Errorf("unexpected exemplar %+v", e)
+	}
+	out, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != in {
+		t.Errorf("got %s, want %s", out, in)
+	}
+	if got := e.TraceID("span_id"); got != "" {
+		t.Errorf("expected no trace ID for unknown label, got %q", got)
+	}
+}
+
+func TestClient_ExemplarsMerge(t *testing.T) {
+	queries := make(chan string, 2)
+	handler := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/v1/query_exemplars" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			queries <- r.URL.Query().Get("query")
+			w.Header().Set("Content-Type", "application/json")
+			if _, err := w.Write([]byte(body)); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		}
+	}
+	a := httptest.NewServer(handler(`{"status":"success","data":[{"seriesLabels":{"__name__":"latency_bucket","job":"api"},"exemplars":[{"labels":{"trace_id":"t2"},"value":"2","timestamp":20},{"labels":{"trace_id":"t1"},"value":"1","timestamp":10}]}]}`))
+	defer a.Close()
+	b := httptest.NewServer(handler(`{"status":"success","data":[{"seriesLabels":{"__name__":"latency_bucket","job":"api"},"exemplars":[{"labels":{"trace_id":"t1"},"value":"1","timestamp":10},{"labels":{"traceID":"t3"},"value":"3","timestamp":15}]},{"seriesLabels":{"__name__":"latency_bucket","job":"db"},"exemplars":[]}]}`))
+	defer b.Close()
+
+	c, err := New(WithBackends(Backend{URL: a.URL}, Backend{URL: b.URL}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := c.Exemplars(context.Background(), "latency_bucket", MetadataQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q := <-queries; q != "latency_bucket" {
+		t.Errorf("expected query to be sent, got %q", q)
+	}
+	if len(resp.Data) != 2 {
+		t.Fatalf("expected two series, got %+v", resp.Data)
+	}
+	var ids []string
+	for _, e := range resp.Data[0].Exemplars {
+		ids = append(ids, e.TraceID())
+	}
+	if len(ids) != 3 || ids[0] != "t1" || ids[1] != "t3" || ids[2] != "t2" {
+		t.Errorf("expected deduplicated exemplars in time order, got %v", ids)
+	}
+}
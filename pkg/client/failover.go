@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// failover sends call to the backends one at a time in priority order,
+// moving to the next one only when the previous failed, timed out or was
+// skipped. It stops at the first success and returns the outcomes of the
+// backends that were tried, in backend order
+func failover[T any](ctx context.Context, c *Client, backends []Backend, backendTimeout time.Duration, call backendCall[T]) []outcome[T] {
+	var outcomes []outcome[T]
+	for i, b := range backends {
+		if ctx.Err() != nil {
+			break
+		}
+		res := runJob(ctx, c, PrometheusQueryJob{Index: i, Backend: b}, backendTimeout, call)
+		outcomes = append(outcomes, res)
+		if res.succeeded() {
+			break
+		}
+		if i+1 < len(backends) {
+			c.logger.Printf("backend %s failed, failing over to %s", res.Backend, backends[i+1])
+		}
+	}
+	return outcomes
+}
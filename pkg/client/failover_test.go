@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientQuery_Failover(t *testing.T) {
+	var primaryCalls, secondaryCalls, cancelled atomic.Int32
+	var primaryStatus atomic.Int32
+	primaryStatus.Store(http.StatusBadGateway)
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		if status := int(primaryStatus.Load()); status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		mockPrometheusHandler(t)(w, r)
+	}))
+	defer primary.Close()
+	secondary := replicaServer(t, 0, &secondaryCalls, &cancelled)
+	defer secondary.Close()
+
+	c, err := New(
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithBackends(Backend{Name: "secondary", URL: secondary.URL}, Backend{Name: "primary", URL: primary.URL, Weight: 1}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	merged, err := c.Query(context.Background(), QueryData{Query: "up", Mode: QueryModeFailover})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merged.AnsweredBy != "secondary" {
+		t.Errorf("expected the secondary to answer, got %q", merged.AnsweredBy)
+	}
+	if len(merged.Backends) != 2 || merged.Backends[0].Backend != "primary" || merged.Backends[0].Status != BackendStatusError {
+		t.Errorf("expected the primary to be tried first, got %+v", merged.Backends)
+	}
+	if !strings.Contains(strings.Join(merged.Warnings, "\n"), "backend primary failed") {
+		t.Errorf("expected a warning for the primary, got %v", merged.Warnings)
+	}
+
+	primaryStatus.Store(http.StatusOK)
+	merged, err = c.Query(context.Background(), QueryData{Query: "up", Mode: QueryModeFailover})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merged.AnsweredBy != "primary" || len(merged.Backends) != 1 || secondaryCalls.Load() != 1 {
+		t.Errorf("expected only the primary to be queried, got %+v and %d secondary calls", merged.Backends, secondaryCalls.Load())
+	}
+}
+
+func TestClientQuery_FailoverOnTimeout(t *testing.T) {
+	var slowCalls, slowCancelled, fastCalls, fastCancelled atomic.Int32
+	slow := replicaServer(t, 2*time.Second, &slowCalls, &slowCancelled)
+	defer slow.Close()
+	fast := replicaServer(t, 0, &fastCalls, &fastCancelled)
+	defer fast.Close()
+
+	c, err := New(WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	merged, err := c.Query(context.Background(), QueryData{
+		Query:          "up",
+		Backends:       []string{slow.URL, fast.URL},
+		BackendTimeout: 50 * time.Millisecond,
+		Mode:           QueryModeFailover,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merged.AnsweredBy != fast.URL || merged.Backends[0].Status != BackendStatusError {
+		t.Errorf("expected a failover after the timeout, got %q and %+v", merged.AnsweredBy, merged.Backends)
+	}
+
+	_, err = c.Query(context.Background(), QueryData{Query: "up", Backends: []string{slow.URL}, BackendTimeout: 50 * time.Millisecond, Mode: QueryModeFailover})
+	if err == nil {
+		t.Error("expected an error when every backend fails, got nil")
+	}
+	if _, err := c.Query(context.Background(), QueryData{Query: "up", Mode: QueryModeFailover, Tenants: []string{"a"}}); err == nil {
+		t.Error("expected an error for failover across tenants, got nil")
+	}
+}
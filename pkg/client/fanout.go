@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// PrometheusQueryJob is one backend request handed to the worker pool
+type PrometheusQueryJob struct {
+	// Index is the position of the backend in the request's backend list
+	Index   int
+	Backend Backend
+}
+
+// backendCall performs one request against a backend, returning its decoded
+// result and any warnings the backend attached to it
+type backendCall[T any] func(ctx context.Context, b Backend) (T, []string, error)
+
+// outcome is the result of a backendCall against one backend
+type outcome[T any] struct {
+	index    int
+	Backend  string
+	Response T
+	Status   BackendStatus
+}
+
+func (o outcome[T]) succeeded() bool {
+	return o.Status.Status == BackendStatusSuccess
+}
+
+// backendResult is the outcome of a query against one backend
+type backendResult = outcome[*PrometheusResponse]
+
+// fanOut runs call against every backend on the client's worker pool and
+// returns the outcomes in backend order
+func fanOut[T any](ctx context.Context, c *Client, backends []Backend, backendTimeout time.Duration, call backendCall[T]) []outcome[T] {
+	jobs := make(chan PrometheusQueryJob, len(backends))
+	results := make(chan outcome[T], len(backends))
+	var wg sync.WaitGroup
+
+	for range min(c.workers, len(backends)) {
+		go prometheusQueryWorker(ctx, c, jobs, results, &wg, backendTimeout, call)
+	}
+
+	wg.Add(len(backends))
+	for i, backend := range backends {
+		jobs <- PrometheusQueryJob{Index: i, Backend: backend}
+	}
+	close(jobs)
+	wg.Wait()
+
+	ordered := make([]outcome[T], len(backends))
+	for range backends {
+		res := <-results
+		ordered[res.index] = res
+	}
+	return ordered
+}
+
+func prometheusQueryWorker[T any](ctx context.Context, c *Client, jobs <-chan PrometheusQueryJob, results chan<- outcome[T], wg *sync.WaitGroup, backendTimeout time.Duration, call backendCall[T]) {
+	for job := range jobs {
+		results <- runJob(ctx, c, job, backendTimeout, call)
+		wg.Done()
+	}
+}
+
+// runJob runs a single job and records its outcome. Backends whose last
+// health check found them down or whose circuit breaker is open are skipped.
+// Failed requests are retried under the client's retry policy when the error
+// is retryable, and the outcome reports the last error and the number of
+// attempts
+func runJob[T any](ctx context.Context, c *Client, job PrometheusQueryJob, backendTimeout time.Duration, call backendCall[T]) outcome[T] {
+	name := job.Backend.String()
+	res := outcome[T]{
+		index:   job.Index,
+		Backend: name,
+		Status:  BackendStatus{Backend: name, Status: BackendStatusError},
+	}
+	if health, ok := c.health.get(job.Backend.URL); ok {
+		res.Status.Health = health.State
+		if health.State == HealthDown {
+			res.Status.Status = BackendStatusSkipped
+			res.Status.Error = "health check failed: " + health.Error
+			return res
+		}
+	}
+	if !c.breakers.allow(job.Backend.URL) {
+		res.Status.Status = BackendStatusSkipped
+		res.Status.Error = "circuit breaker open"
+		return res
+	}
+	if job.Backend.Timeout > 0 {
+		backendTimeout = job.Backend.Timeout
+	}
+
+	started := time.Now()
+	for attempt := 1; ; attempt++ {
+		res.Status.Attempts = attempt
+		attemptStarted := time.Now()
+		resp, warnings, err := runAttempt(ctx, c, job.Backend, backendTimeout, call)
+		res.Status.Latency = time.Since(started)
+		if err == nil {
+			c.latencies.observe(job.Backend.URL, time.Since(attemptStarted))
+			c.breakers.done(job.Backend.URL, nil)
+			res.Response = resp
+			res.Status.Status = BackendStatusSuccess
+			res.Status.HTTPStatus = http.StatusOK
+			res.Status.Error = ""
+			res.Status.Warnings = warnings
+			return res
+		}
+		c.logger.Printf("error querying backend %s (attempt %d): %v", name, attempt, err)
+		res.Status.Error = err.Error()
+		res.Status.HTTPStatus = 0
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			res.Status.HTTPStatus = apiErr.StatusCode
+		}
+		if attempt >= c.retry.MaxAttempts || ctx.Err() != nil || !retryable(err) || !c.retry.wait(ctx, attempt) {
+			c.breakers.done(job.Backend.URL, err)
+			return res
+		}
+	}
+}
+
+// runAttempt sends one request under the rate limiters. The backend's own
+// limiter is acquired before the shared one so that a throttled backend does
+// not hold shared tokens. The backend timeout covers the request only, not the
+// wait for tokens
+func runAttempt[T any](ctx context.Context, c *Client, b Backend, backendTimeout time.Duration, call backendCall[T]) (T, []string, error) {
+	var zero T
+	if limiter := b.Limiter; limiter != nil {
+		token, err := limiter.AcquireContext(ctx)
+		if err != nil {
+			return zero, nil, fmt.Errorf("acquiring backend rate limit token: %w", err)
+		}
+		defer limiter.Release(token)
+	}
+	token, err := c.limiter.AcquireContext(ctx)
+	if err != nil {
+		return zero, nil, fmt.Errorf("acquiring rate limit token: %w", err)
+	}
+	defer c.limiter.Release(token)
+	c.logger.Printf("Rate Limit Token %s acquired at %s...", token.ID, time.Now().UTC())
+
+	if backendTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, backendTimeout)
+		defer cancel()
+	}
+	return call(ctx, b)
+}
+
+// settle applies a partial response strategy to outcomes. It returns the
+// successful outcomes, the status of every backend and the warnings to report,
+// with an error in strict mode or when every backend failed
+func settle[T any](outcomes []outcome[T], strategy PartialResponseStrategy) ([]outcome[T], []BackendStatus, []string, error) {
+	var succeeded []outcome[T]
+	var statuses []BackendStatus
+	var warnings []string
+	for _, res := range outcomes {
+		statuses = append(statuses, res.Status)
+		if !res.succeeded() {
+			verb := "failed"
+			if res.Status.Status == BackendStatusSkipped {
+				verb = "skipped"
+			}
+			warnings = append(warnings, fmt.Sprintf("backend %s %s: %s", res.Backend, verb, res.Status.Error))
+			continue
+		}
+		succeeded = append(succeeded, res)
+		if res.Status.Health == HealthDegraded {
+			warnings = append(warnings, fmt.Sprintf("backend %s is %s, its results may be incomplete", res.Backend, HealthDegraded))
+		}
+		warnings = appendUnique(warnings, res.Status.Warnings...)
+	}
+	failed := len(outcomes) - len(succeeded)
+	if failed > 0 && (strategy == PartialResponseStrict || len(succeeded) == 0) {
+		return nil, statuses, warnings, &PartialResponseError{Backends: statuses}
+	}
+	return succeeded, statuses, warnings, nil
+}
+
+// settleFirst reports the outcome of a request that only needs one backend to
+// answer: the first success with its warnings, or an error when every backend
+// that was tried failed. Failures before the answer become warnings
+func settleFirst[T any](outcomes []outcome[T]) ([]outcome[T], []BackendStatus, []string, error) {
+	var statuses []BackendStatus
+	var first []outcome[T]
+	var warnings []string
+	for _, res := range outcomes {
+		statuses = append(statuses, res.Status)
+		switch {
+		case res.succeeded() && len(first) == 0:
+			first = append(first, res)
+			if res.Status.Health == HealthDegraded {
+				warnings = append(warnings, fmt.Sprintf("backend %s is %s, its results may be incomplete", res.Backend, HealthDegraded))
+			}
+			warnings = appendUnique(warnings, res.Status.Warnings...)
+		case res.Status.Status == BackendStatusError:
+			warnings = append(warnings, fmt.Sprintf("backend %s failed: %s", res.Backend, res.Status.Error))
+		case res.Status.Status == BackendStatusSkipped:
+			warnings = append(warnings, fmt.Sprintf("backend %s skipped: %s", res.Backend, res.Status.Error))
+		}
+	}
+	if len(first) == 0 {
+		return nil, statuses, warnings, &PartialResponseError{Backends: statuses}
+	}
+	return first, statuses, warnings, nil
+}
@@ -0,0 +1,245 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// MetricMetadata describes a metric as reported by /api/v1/metadata
+type MetricMetadata struct {
+	Type string `json:"type"`
+	Help string `json:"help"`
+	Unit string `json:"unit"`
+}
+
+// MetricMetadataResponse is the union of the metric metadata found on every
+// backend, keyed by metric name
+type MetricMetadataResponse struct {
+	Status   string                      `json:"status"`
+	Data     map[string][]MetricMetadata `json:"data"`
+	Warnings []string                    `json:"warnings,omitempty"`
+	Backends []BackendStatus             `json:"backends,omitempty"`
+}
+
+// Target is an active scrape target, labelled with the backend scraping it
+type Target struct {
+	Backend            string    `json:"backend"`
+	DiscoveredLabels   Labels    `json:"discoveredLabels"`
+	Labels             Labels    `json:"labels"`
+	ScrapePool         string    `json:"scrapePool"`
+	ScrapeURL          string    `json:"scrapeUrl"`
+	GlobalURL          string    `json:"globalUrl"`
+	LastError          string    `json:"lastError"`
+	LastScrape         time.Time `json:"lastScrape"`
+	LastScrapeDuration float64   `json:"lastScrapeDuration"`
+	Health             string    `json:"health"`
+	ScrapeInterval     string    `json:"scrapeInterval"`
+	ScrapeTimeout      string    `json:"scrapeTimeout"`
+}
+
+// TargetHealthDown is the health of a target whose last scrape failed
+const TargetHealthDown = "down"
+
+// DroppedTarget is a discovered target dropped by relabelling
+type DroppedTarget struct {
+	Backend          string `json:"backend"`
+	DiscoveredLabels Labels `json:"discoveredLabels"`
+}
+
+// TargetsData lists the targets of every backend
+type TargetsData struct {
+	ActiveTargets  []Target        `json:"activeTargets"`
+	DroppedTargets []DroppedTarget `json:"droppedTargets"`
+}
+
+// TargetsResponse holds the scrape targets of every backend
+type TargetsResponse struct {
+	Status   string          `json:"status"`
+	Data     TargetsData     `json:"data"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Backends []BackendStatus `json:"backends,omitempty"`
+}
+
+// Alert is a pending or firing alert, labelled with the backend evaluating it
+type Alert struct {
+	Backend     string     `json:"backend"`
+	Labels      Labels     `json:"labels"`
+	Annotations Labels     `json:"annotations"`
+	State       string     `json:"state"`
+	ActiveAt    *time.Time `json:"activeAt,omitempty"`
+	Value       string     `json:"value"`
+}
+
+// Rule is a recording or alerting rule. Alerting rules also carry their state,
+// duration, annotations and active alerts
+type Rule struct {
+	Name           string    `json:"name"`
+	Query          string    `json:"query"`
+	Type           string    `json:"type"`
+	Health         string    `json:"health"`
+	LastError      string    `json:"lastError,omitempty"`
+	Labels         Labels    `json:"labels,omitempty"`
+	State          string    `json:"state,omitempty"`
+	Duration       float64   `json:"duration,omitempty"`
+	Annotations    Labels    `json:"annotations,omitempty"`
+	Alerts         []Alert   `json:"alerts,omitempty"`
+	EvaluationTime float64   `json:"evaluationTime"`
+	LastEvaluation time.Time `json:"lastEvaluation"`
+}
+
+// RuleHealthError is the health of a rule whose last evaluation failed
+const RuleHealthError = "err"
+
+// RuleGroup is a group of rules, labelled with the backend evaluating it
+type RuleGroup struct {
+	Backend        string    `json:"backend"`
+	Name           string    `json:"name"`
+	File           string    `json:"file"`
+	Interval       float64   `json:"interval"`
+	Rules          []Rule    `json:"rules"`
+	EvaluationTime float64   `json:"evaluationTime"`
+	LastEvaluation time.Time `json:"lastEvaluation"`
+}
+
+// FailingRules returns the rules of the group whose last evaluation failed
+func (g RuleGroup) FailingRules() []Rule {
+	var failing []Rule
+	for _, r := range g.Rules {
+		if r.Health == RuleHealthError {
+			failing = append(failing, r)
+		}
+	}
+	return failing
+}
+
+// RulesData lists the rule groups of every backend
+type RulesData struct {
+	Groups []RuleGroup `json:"groups"`
+}
+
+// RulesResponse holds the rule groups of every backend
+type RulesResponse struct {
+	Status   string          `json:"status"`
+	Data     RulesData       `json:"data"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Backends []BackendStatus `json:"backends,omitempty"`
+}
+
+// AlertsData lists the alerts of every backend
+type AlertsData struct {
+	Alerts []Alert `json:"alerts"`
+}
+
+// AlertsResponse holds the active alerts of every backend
+type AlertsResponse struct {
+	Status   string          `json:"status"`
+	Data     AlertsData      `json:"data"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Backends []BackendStatus `json:"backends,omitempty"`
+}
+
+// MetricMetadata lists the metadata of metric, or of every metric when empty,
+// on every backend. Identical entries reported by several backends are merged
+func (c *Client) MetricMetadata(ctx context.Context, metric string, q MetadataQuery) (*MetricMetadataResponse, error) {
+	params := url.Values{}
+	if metric != "" {
+		params.Set("metric", metric)
+	}
+	outcomes, statuses, warnings, err := fanOutMetadata[map[string][]MetricMetadata](ctx, c, q, "/api/v1/metadata", params)
+	if err != nil {
+		return nil, err
+	}
+	resp := &MetricMetadataResponse{Status: "success", Data: map[string][]MetricMetadata{}, Warnings: warnings, Backends: statuses}
+	for _, o := range outcomes {
+		for name, entries := range o.Response {
+			for _, m := range entries {
+				if !containsMetadata(resp.Data[name], m) {
+					resp.Data[name] = append(resp.Data[name], m)
+				}
+			}
+		}
+	}
+	return resp, nil
+}
+
+func containsMetadata(entries []MetricMetadata, m MetricMetadata) bool {
+	for _, e := range entries {
+		if e == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Targets lists the scrape targets of every backend. state filters them as
+// the Prometheus API does: active, dropped or any, empty meaning any
+func (c *Client) Targets(ctx context.Context, state string, q MetadataQuery) (*TargetsResponse, error) {
+	params := url.Values{}
+	if state != "" {
+		params.Set("state", state)
+	}
+	outcomes, statuses, warnings, err := fanOutMetadata[TargetsData](ctx, c, q, "/api/v1/targets", params)
+	if err != nil {
+		return nil, err
+	}
+	resp := &TargetsResponse{
+		Status:   "success",
+		Data:     TargetsData{ActiveTargets: []Target{}, DroppedTargets: []DroppedTarget{}},
+		Warnings: warnings,
+		Backends: statuses,
+	}
+	for _, o := range outcomes {
+		for _, t := range o.Response.ActiveTargets {
+			t.Backend = o.Backend
+			resp.Data.ActiveTargets = append(resp.Data.ActiveTargets, t)
+		}
+		for _, t := range o.Response.DroppedTargets {
+			t.Backend = o.Backend
+			resp.Data.DroppedTargets = append(resp.Data.DroppedTargets, t)
+		}
+	}
+	return resp, nil
+}
+
+// Rules lists the rule groups of every backend. ruleType keeps only alert or
+// record rules, empty meaning both
+func (c *Client) Rules(ctx context.Context, ruleType string, q MetadataQuery) (*RulesResponse, error) {
+	params := url.Values{}
+	if ruleType != "" {
+		params.Set("type", ruleType)
+	}
+	outcomes, statuses, warnings, err := fanOutMetadata[RulesData](ctx, c, q, "/api/v1/rules", params)
+	if err != nil {
+		return nil, err
+	}
+	resp := &RulesResponse{Status: "success", Data: RulesData{Groups: []RuleGroup{}}, Warnings: warnings, Backends: statuses}
+	for _, o := range outcomes {
+		for _, g := range o.Response.Groups {
+			g.Backend = o.Backend
+			for i := range g.Rules {
+				for j := range g.Rules[i].Alerts {
+					g.Rules[i].Alerts[j].Backend = o.Backend
+				}
+			}
+			resp.Data.Groups = append(resp.Data.Groups, g)
+		}
+	}
+	return resp, nil
+}
+
+// Alerts lists the pending and firing alerts of every backend
+func (c *Client) Alerts(ctx context.Context, q MetadataQuery) (*AlertsResponse, error) {
+	outcomes, statuses, warnings, err := fanOutMetadata[AlertsData](ctx, c, q, "/api/v1/alerts", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp := &AlertsResponse{Status: "success", Data: AlertsData{Alerts: []Alert{}}, Warnings: warnings, Backends: statuses}
+	for _, o := range outcomes {
+		for _, a := range o.Response.Alerts {
+			a.Backend = o.Backend
+			resp.Data.Alerts = append(resp.Data.Alerts, a)
+		}
+	}
+	return resp, nil
+}
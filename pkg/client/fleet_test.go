@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// mockFleetHandler serves fixed bodies for the status endpoints by path
+func mockFleetHandler(t *testing.T, bodies map[string]string) http.HandlerFunc {
+	t.Helper()
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}
+}
+
+func TestClient_FleetEndpoints(t *testing.T) {
+	a := httptest.NewServer(mockFleetHandler(t, map[string]string{
+		"/api/v1/metadata": `{"status":"success","data":{"up":[{"type":"gauge","help":"Target is up.","unit":""}]}}`,
+		"/api/v1/targets":  `{"status":"success","data":{"activeTargets":[{"scrapePool":"node","labels":{"instance":"a:9100"},"health":"down","lastError":"connection refused"}],"droppedTargets":[]}}`,
+		"/api/v1/rules":    `{"status":"success","data":{"groups":[{"name":"node","file":"node.yml","rules":[{"name":"NodeDown","type":"alerting","health":"err","lastError":"bad query","alerts":[{"labels":{"alertname":"NodeDown"},"state":"firing","value":"1"}]}]}]}}`,
+		"/api/v1/alerts":   `{"status":"success","data":{"alerts":[{"labels":{"alertname":"NodeDown"},"state":"firing","activeAt":"2024-01-01T00:00:00Z","value":"1e+00"}]}}`,
+	}))
+	defer a.Close()
+	b := httptest.NewServer(mockFleetHandler(t, map[string]string{
+		"/api/v1/metadata": `{"status":"success","data":{"up":[{"type":"gauge","help":"Target is up.","unit":""}],"go_goroutines":[{"type":"gauge","help":"Goroutines.","unit":""}]}}`,
+		"/api/v1/targets":  `{"status":"success","data":{"activeTargets":[{"scrapePool":"node","labels":{"instance":"b:9100"},"health":"up"}],"droppedTargets":[{"discoveredLabels":{"__address__":"c:9100"}}]}}`,
+		"/api/v1/rules":    `{"status":"success","data":{"groups":[]}}`,
+		"/api/v1/alerts":   `{"status":"success","data":{"alerts":[]}}`,
+	}))
+	defer b.Close()
+
+	c, err := New(WithBackends(Backend{Name: "a", URL: a.URL}, Backend{Name: "b", URL: b.URL}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	metadata, err := c.MetricMetadata(ctx, "", MetadataQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metadata.Data) != 2 || len(metadata.Data["up"]) != 1 {
+		t.Errorf("expected identical metadata to be merged, got %+v", metadata.Data)
+	}
+
+	targets, err := c.Targets(ctx, "", MetadataQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := targets.Data.ActiveTargets; len(got) != 2 || got[0].Backend != "a" || got[1].Backend != "b" || got[0].Health != TargetHealthDown {
+		t.Errorf("expected active targets labelled by backend, got %+v", got)
+	}
+	if got := targets.Data.DroppedTargets; len(got) != 1 || got[0].Backend != "b" {
+		t.Errorf("expected dropped target from b, got %+v", got)
+	}
+
+	rules, err := c.Rules(ctx, "", MetadataQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules.Data.Groups) != 1 || rules.Data.Groups[0].Backend != "a" {
+		t.Fatalf("expected one group from a, got %+v", rules.Data.Groups)
+	}
+	failing := rules.Data.Groups[0].FailingRules()
+	if len(failing) != 1 || failing[0].LastError != "bad query" || failing[0].Alerts[0].Backend != "a" {
+		t.Errorf("expected failing rule with labelled alerts, got %+v", failing)
+	}
+
+	alerts, err := c.Alerts(ctx, MetadataQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := alerts.Data.Alerts; len(got) != 1 || got[0].Backend != "a" || got[0].ActiveAt == nil {
+		t.Errorf("expected one firing alert from a, got %+v", got)
+	}
+}
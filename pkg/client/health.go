@@ -0,0 +1,230 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHealthCheckTimeout bounds each health probe when the client has no
+// backend timeout
+const DefaultHealthCheckTimeout = 5 * time.Second
+
+// HealthState is the result of the health checks of a backend
+type HealthState string
+
+const (
+	// HealthReady is a backend that reports ready and serves its build info
+	HealthReady HealthState = "ready"
+
+	// HealthDegraded is a backend that answers only one of the probes, such
+	// as a Prometheus still replaying its WAL. Queries are still sent to it
+	// and its results are flagged
+	HealthDegraded HealthState = "degraded"
+
+	// HealthDown is a backend that answers neither probe. Queries skip it
+	// until a later check finds it up
+	HealthDown HealthState = "down"
+)
+
+// BuildInfo is the data of the /api/v1/status/buildinfo endpoint
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Branch    string `json:"branch"`
+	BuildUser string `json:"buildUser"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+// HealthStatus is the outcome of the last health check of a backend
+type HealthStatus struct {
+	Backend string      `json:"backend"`
+	URL     string      `json:"url"`
+	State   HealthState `json:"state"`
+
+	// Ready is whether the readiness endpoint answered with 200 OK
+	Ready bool `json:"ready"`
+
+	// BuildInfo is nil when the build info endpoint failed
+	BuildInfo *BuildInfo `json:"buildInfo,omitempty"`
+
+	CheckedAt time.Time     `json:"checkedAt"`
+	Latency   time.Duration `json:"latency"`
+
+	// Error describes the probes that failed
+	Error string `json:"error,omitempty"`
+}
+
+// healthSet holds the last health status of every backend URL
+type healthSet struct {
+	mu       sync.Mutex
+	statuses map[string]HealthStatus
+}
+
+func (s *healthSet) get(url string) (HealthStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.statuses[url]
+	return status, ok
+}
+
+func (s *healthSet) set(status HealthStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.statuses == nil {
+		s.statuses = make(map[string]HealthStatus)
+	}
+	s.statuses[status.URL] = status
+}
+
+// CheckHealth probes the readiness endpoint, see Backend.ReadyPath, and
+// /api/v1/status/buildinfo on every configured backend, or on the ones refs
+// name as in QueryData.Backends, and returns their health in backend order.
+// The results are kept by the client: queries skip backends that are down
+// and flag the results of degraded ones
+func (c *Client) CheckHealth(ctx context.Context, refs ...string) []HealthStatus {
+	backends := c.Backends()
+	if len(refs) > 0 {
+		backends = c.backendsFor(refs)
+	}
+	statuses := make([]HealthStatus, len(backends))
+	sem := make(chan struct{}, c.workers)
+	var wg sync.WaitGroup
+	for i, b := range backends {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			statuses[i] = c.probe(ctx, b)
+			c.health.set(statuses[i])
+		}()
+	}
+	wg.Wait()
+	return statuses
+}
+
+// StartHealthChecks checks the health of the configured backends right away
+// and then every interval, in the background until ctx is done
+func (c *Client) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			c.CheckHealth(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Health returns the last health status of the configured backends that have
+// been checked, in backend order
+func (c *Client) Health() []HealthStatus {
+	var statuses []HealthStatus
+	for _, b := range c.backends {
+		if status, ok := c.health.get(b.URL); ok {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// probe runs both health probes against a backend
+func (c *Client) probe(ctx context.Context, b Backend) HealthStatus {
+	timeout := c.backendTimeout
+	if b.Timeout > 0 {
+		timeout = b.Timeout
+	}
+	if timeout == 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	status := HealthStatus{Backend: b.String(), URL: b.URL, CheckedAt: time.Now()}
+	var errs []string
+	code, body, err := c.getEndpoint(ctx, b, b.readyURL())
+	switch {
+	case err != nil:
+		errs = append(errs, fmt.Sprintf("ready: %v", err))
+	case code != http.StatusOK:
+		errs = append(errs, fmt.Sprintf("ready: %v", newAPIError(code, body, nil)))
+	default:
+		status.Ready = true
+	}
+
+	if info, err := c.buildInfo(ctx, b); err != nil {
+		errs = append(errs, fmt.Sprintf("buildinfo: %v", err))
+	} else {
+		status.BuildInfo = info
+	}
+	status.Latency = time.Since(status.CheckedAt)
+
+	switch {
+	case status.Ready && status.BuildInfo != nil:
+		status.State = HealthReady
+	case status.Ready || status.BuildInfo != nil:
+		status.State = HealthDegraded
+	default:
+		status.State = HealthDown
+	}
+	status.Error = strings.Join(errs, "; ")
+	return status
+}
+
+// buildInfo fetches the build info of a backend
+func (c *Client) buildInfo(ctx context.Context, b Backend) (*BuildInfo, error) {
+	code, body, err := c.getEndpoint(ctx, b, strings.TrimRight(b.URL, "/")+"/api/v1/status/buildinfo")
+	if err != nil {
+		return nil, err
+	}
+	var envelope apiResponse
+	decodeErr := json.Unmarshal(body, &envelope)
+	if code/100 != 2 || decodeErr != nil || envelope.Status != "success" {
+		if decodeErr != nil {
+			return nil, newAPIError(code, body, nil)
+		}
+		return nil, newAPIError(code, body, &envelope)
+	}
+	var info BuildInfo
+	if err := json.Unmarshal(envelope.Data, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// getEndpoint sends a GET request without parameters to endpoint, a URL of
+// backend b, returning the status code and body of the response
+func (c *Client) getEndpoint(ctx context.Context, b Backend, endpoint string) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	if b.Tenant != "" {
+		req.Header.Set(TenantHeader, b.Tenant)
+	}
+	resp, err := c.httpClientFor(b).Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			c.logger.Printf("error closing response body: %v", cerr)
+		}
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// healthHandler serves the health endpoints, answering /-/ready with ready
+// and buildinfo only when withBuildInfo is set, and queries otherwise
+func healthHandler(t *testing.T, ready int, withBuildInfo bool, queries *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/-/ready":
+			w.WriteHeader(ready)
+		case "/api/v1/status/buildinfo":
+			if !withBuildInfo {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if _, err := w.Write([]byte(`{"status":"success","data":{"version":"2.53.0","revision":"abc","goVersion":"go1.22"}}`)); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		default:
+			queries.Add(1)
+			mockPrometheusHandler(t)(w, r)
+		}
+	}
+}
+
+func TestClient_CheckHealth(t *testing.T) {
+	var readyQueries, degradedQueries, downQueries atomic.Int32
+	ready := httptest.NewServer(healthHandler(t, http.StatusOK, true, &readyQueries))
+	defer ready.Close()
+	degraded := httptest.NewServer(healthHandler(t, http.StatusServiceUnavailable, true, &degradedQueries))
+	defer degraded.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downQueries.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	c, err := New(WithBackends(
+		Backend{Name: "ready", URL: ready.URL},
+		Backend{Name: "degraded", URL: degraded.URL},
+		Backend{Name: "down", URL: down.URL},
+	))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	statuses := c.CheckHealth(context.Background())
+	if len(statuses) != 3 {
+		t.Fatalf("expected three statuses, got %+v", statuses)
+	}
+	if s := statuses[0]; s.State != HealthReady || !s.Ready || s.BuildInfo == nil || s.BuildInfo.Version != "2.53.0" || s.Error != "" {
+		t.Errorf("unexpected ready status %+v", s)
+	}
+	if s := statuses[1]; s.State != HealthDegraded || s.Ready || !strings.Contains(s.Error, "ready: HTTP 503") {
+		t.Errorf("unexpected degraded status %+v", s)
+	}
+	if s := statuses[2]; s.State != HealthDown || !strings.Contains(s.Error, "buildinfo: HTTP 502") {
+		t.Errorf("unexpected down status %+v", s)
+	}
+	downQueries.Store(0)
+
+	merged, err := c.Query(context.Background(), QueryData{Query: "up"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if downQueries.Load() != 0 || readyQueries.Load() != 1 || degradedQueries.Load() != 1 {
+		t.Errorf("expected queries to skip the down backend, got %d, %d and %d", readyQueries.Load(), degradedQueries.Load(), downQueries.Load())
+	}
+	if s := merged.Backends[2]; s.Status != BackendStatusSkipped || s.Health != HealthDown {
+		t.Errorf("unexpected status of the down backend %+v", s)
+	}
+	if s := merged.Backends[1]; s.Status != BackendStatusSuccess || s.Health != HealthDegraded {
+		t.Errorf("unexpected status of the degraded backend %+v", s)
+	}
+	if !strings.Contains(strings.Join(merged.Warnings, "\n"), "backend degraded is degraded") {
+		t.Errorf("expected a warning for the degraded backend, got %v", merged.Warnings)
+	}
+	if got := c.Health(); len(got) != 3 || got[0].Backend != "ready" {
+		t.Errorf("unexpected health %+v", got)
+	}
+
+	downQueries.Store(0)
+	statuses = c.CheckHealth(context.Background(), "ready")
+	if len(statuses) != 1 || statuses[0].Backend != "ready" || downQueries.Load() != 0 {
+		t.Errorf("expected only the named backend to be probed, got %+v", statuses)
+	}
+}
+
+func TestClient_CheckHealthPathPrefix(t *testing.T) {
+	// a Cortex serving the Prometheus API under /prometheus and /ready at the root
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ready":
+			w.WriteHeader(http.StatusOK)
+		case "/prometheus/api/v1/status/buildinfo":
+			w.Header().Set("Content-Type", "application/json")
+			if _, err := w.Write([]byte(`{"status":"success","data":{"version":"1.18.0"}}`)); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBackends(
+		Backend{Name: "ready-path", URL: ts.URL + "/prometheus", ReadyPath: "/ready"},
+		Backend{Name: "default", URL: ts.URL + "/prometheus/"},
+	))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	statuses := c.CheckHealth(context.Background())
+	if s := statuses[0]; s.State != HealthReady || s.Error != "" {
+		t.Errorf("expected the ready path to be probed at the host root, got %+v", s)
+	}
+	if s := statuses[1]; s.State != HealthDegraded || !strings.Contains(s.Error, "ready: HTTP 404") {
+		t.Errorf("expected /-/ready under the URL without a ready path, got %+v", s)
+	}
+
+	if _, err := New(WithBackends(Backend{URL: ts.URL, ReadyPath: "ready"})); err == nil {
+		t.Error("expected error for a relative ready path, got nil")
+	}
+}
+
+func TestClient_StartHealthChecks(t *testing.T) {
+	var queries atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthHandler(t, int(status.Load()), false, &queries)(w, r)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBackends(Backend{URL: ts.URL}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.StartHealthChecks(ctx, 10*time.Millisecond)
+
+	waitFor := func(state HealthState) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if h := c.Health(); len(h) == 1 && h[0].State == state {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("backend never became %s, got %+v", state, c.Health())
+	}
+	waitFor(HealthDown)
+	status.Store(http.StatusOK)
+	waitFor(HealthDegraded)
+}
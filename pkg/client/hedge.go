@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// latencyWindow is the number of recent latencies kept per backend URL
+	latencyWindow = 128
+
+	// hedgeMinSamples is the number of latencies needed before the hedging
+	// delay follows the percentile rather than HedgePolicy.MinDelay
+	hedgeMinSamples = 10
+)
+
+// DefaultHedgePolicy hedges a query once the replica it was sent to is
+// slower than 95% of recent answers of the replicas, waiting at least 50ms
+var DefaultHedgePolicy = HedgePolicy{
+	Percentile: 0.95,
+	MinDelay:   50 * time.Millisecond,
+}
+
+// HedgePolicy controls when QueryModeHedge sends a query to the next replica
+type HedgePolicy struct {
+	// Percentile of the recent latencies of the replicas after which the
+	// next replica is tried, between 0 and 1
+	Percentile float64 `yaml:"percentile"`
+
+	// MinDelay is the shortest wait before hedging, also used until enough
+	// latencies have been observed
+	MinDelay time.Duration `yaml:"min_delay"`
+
+	// MaxDelay caps the wait before hedging, zero means no cap
+	MaxDelay time.Duration `yaml:"max_delay"`
+}
+
+func (p HedgePolicy) validate() error {
+	if p.Percentile <= 0 || p.Percentile > 1 {
+		return errors.New("hedge percentile must be greater than 0 and at most 1")
+	}
+	if p.MinDelay < 0 || p.MaxDelay < 0 || (p.MaxDelay > 0 && p.MaxDelay < p.MinDelay) {
+		return errors.New("hedge delays must not be negative and max_delay must not be below min_delay")
+	}
+	return nil
+}
+
+// latencyTracker keeps the latencies of the latest successful requests to
+// every backend URL
+type latencyTracker struct {
+	mu      sync.Mutex
+	samples map[string][]time.Duration
+}
+
+func (t *latencyTracker) observe(url string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.samples == nil {
+		t.samples = make(map[string][]time.Duration)
+	}
+	s := append(t.samples[url], d)
+	if len(s) > latencyWindow {
+		s = s[len(s)-latencyWindow:]
+	}
+	t.samples[url] = s
+}
+
+// percentile returns the p-th percentile of the latencies of the backends,
+// and false when there are too few of them
+func (t *latencyTracker) percentile(backends []Backend, p float64) (time.Duration, bool) {
+	t.mu.Lock()
+	var all []time.Duration
+	for _, b := range backends {
+		all = append(all, t.samples[b.URL]...)
+	}
+	t.mu.Unlock()
+	if len(all) < hedgeMinSamples {
+		return 0, false
+	}
+	slices.Sort(all)
+	i := int(math.Ceil(p*float64(len(all)))) - 1
+	return all[max(i, 0)], true
+}
+
+// hedgeDelay is how long to wait for a replica before hedging to the next
+func (c *Client) hedgeDelay(backends []Backend) time.Duration {
+	delay := c.hedge.MinDelay
+	if p, ok := c.latencies.percentile(backends, c.hedge.Percentile); ok && p > delay {
+		delay = p
+	}
+	if c.hedge.MaxDelay > 0 && delay > c.hedge.MaxDelay {
+		delay = c.hedge.MaxDelay
+	}
+	return delay
+}
+
+// hedge sends call to the backends one at a time, moving to the next one
+// when the previous failed or has not answered within the hedging delay. The
+// first success cancels the other requests. It returns the outcomes of the
+// backends that were sent the request, in backend order. Every request goes
+// through the client's rate limiters like any other
+func hedge[T any](ctx context.Context, c *Client, backends []Backend, backendTimeout time.Duration, call backendCall[T]) []outcome[T] {
+	if len(backends) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	delay := c.hedgeDelay(backends)
+	results := make(chan outcome[T], len(backends))
+	launched := 0
+	launch := func() {
+		job := PrometheusQueryJob{Index: launched, Backend: backends[launched]}
+		launched++
+		go func() {
+			results <- runJob(ctx, c, job, backendTimeout, call)
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	outcomes := make([]outcome[T], 0, len(backends))
+	winner := ""
+	for len(outcomes) < launched {
+		select {
+		case res := <-results:
+			if winner != "" {
+				// cancelled once the winner answered
+				res.Status.Status = BackendStatusCancelled
+				res.Status.Error = fmt.Sprintf("superseded by backend %s", winner)
+			} else if res.succeeded() {
+				winner = res.Backend
+				cancel()
+			}
+			outcomes = append(outcomes, res)
+			if winner == "" && launched < len(backends) {
+				// the replica failed, try the next one right away
+				launch()
+				timer.Reset(delay)
+			}
+		case <-timer.C:
+			if winner == "" && launched < len(backends) {
+				c.logger.Printf("hedging query to backend %s after %s", backends[launched], delay)
+				launch()
+				timer.Reset(delay)
+			}
+		}
+	}
+	slices.SortFunc(outcomes, func(a, b outcome[T]) int {
+		return a.index - b.index
+	})
+	return outcomes
+}
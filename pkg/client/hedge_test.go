@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cortex-client/pkg/ratelimiter"
+)
+
+// replicaServer answers queries after delay, counting requests and noting
+// the ones cancelled by the client
+func replicaServer(t *testing.T, delay time.Duration, calls, cancelled *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		select {
+		case <-time.After(delay):
+			mockPrometheusHandler(t)(w, r)
+		case <-r.Context().Done():
+			cancelled.Add(1)
+		}
+	}))
+}
+
+func TestClientQuery_Hedge(t *testing.T) {
+	var slowCalls, slowCancelled, fastCalls, fastCancelled atomic.Int32
+	slow := replicaServer(t, 2*time.Second, &slowCalls, &slowCancelled)
+	defer slow.Close()
+	fast := replicaServer(t, 0, &fastCalls, &fastCancelled)
+	defer fast.Close()
+
+	c, err := New(WithHedgePolicy(HedgePolicy{Percentile: 0.9, MinDelay: 20 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	started := time.Now()
+	merged, err := c.Query(context.Background(), QueryData{Query: "up", Backends: []string{slow.URL, fast.URL}, Mode: QueryModeHedge})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("expected the hedge to answer before the slow replica, took %s", elapsed)
+	}
+	if merged.AnsweredBy != fast.URL {
+		t.Errorf("expected the fast replica to answer, got %q", merged.AnsweredBy)
+	}
+	if len(merged.Backends) != 2 || merged.Backends[0].Status != BackendStatusCancelled || merged.Backends[1].Status != BackendStatusSuccess {
+		t.Errorf("unexpected backend statuses %+v", merged.Backends)
+	}
+	deadline := time.Now().Add(time.Second)
+	for slowCancelled.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if slowCancelled.Load() != 1 {
+		t.Error("expected the slow request to be cancelled")
+	}
+
+	// a fast first replica is never hedged
+	merged, err = c.Query(context.Background(), QueryData{Query: "up", Backends: []string{fast.URL, slow.URL}, Mode: QueryModeHedge})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged.Backends) != 1 || slowCalls.Load() != 1 {
+		t.Errorf("expected a single request, got %+v and %d slow calls", merged.Backends, slowCalls.Load())
+	}
+}
+
+func TestClientQuery_HedgeAfterFailure(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	var calls, cancelled atomic.Int32
+	ok := replicaServer(t, 0, &calls, &cancelled)
+	defer ok.Close()
+
+	c, err := New(WithRetryPolicy(RetryPolicy{MaxAttempts: 1}), WithHedgePolicy(HedgePolicy{Percentile: 0.9, MinDelay: time.Hour}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	merged, err := c.Query(context.Background(), QueryData{Query: "up", Backends: []string{failing.URL, ok.URL}, Mode: QueryModeHedge})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merged.Backends[0].Status != BackendStatusError || merged.Backends[1].Status != BackendStatusSuccess {
+		t.Errorf("expected the next replica after a failure, got %+v", merged.Backends)
+	}
+
+	_, err = c.Query(context.Background(), QueryData{Query: "up", Backends: []string{failing.URL}, Mode: QueryModeHedge})
+	if err == nil {
+		t.Error("expected an error when every replica fails, got nil")
+	}
+	if _, err := c.Query(context.Background(), QueryData{Query: "up", Mode: QueryModeHedge, Tenants: []string{"a"}}); err == nil {
+		t.Error("expected an error for hedging across tenants, got nil")
+	}
+}
+
+func TestClientQuery_HedgeRespectsRateLimiter(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+		mockPrometheusHandler(t)(w, r)
+	}))
+	defer ts.Close()
+
+	limiter, err := ratelimiter.NewMaxConcurrencyRateLimiter(&ratelimiter.Config{Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := New(WithRateLimiter(limiter), WithHedgePolicy(HedgePolicy{Percentile: 0.9, MinDelay: 10 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.Query(context.Background(), QueryData{Query: "up", Backends: []string{ts.URL, ts.URL, ts.URL}, Mode: QueryModeHedge}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := maxInFlight.Load(); got != 1 {
+		t.Errorf("expected hedges to wait for a rate limit token, got %d requests in flight", got)
+	}
+}
+
+func TestLatencyTracker_Percentile(t *testing.T) {
+	var tracker latencyTracker
+	backends := []Backend{{URL: "http://a"}, {URL: "http://b"}}
+	if _, ok := tracker.percentile(backends, 0.9); ok {
+		t.Error("expected no percentile without samples")
+	}
+	for i := 1; i <= 10; i++ {
+		tracker.observe(backends[i%2].URL, time.Duration(i)*time.Millisecond)
+	}
+	if p, ok := tracker.percentile(backends, 0.9); !ok || p != 9*time.Millisecond {
+		t.Errorf("expected a p90 of 9ms, got %s, %t", p, ok)
+	}
+	for range latencyWindow {
+		tracker.observe("http://a", time.Second)
+	}
+	if p, _ := tracker.percentile(backends[:1], 0.5); p != time.Second {
+		t.Errorf("expected old samples to be dropped, got a median of %s", p)
+	}
+}
+
+func TestParseQueryMode(t *testing.T) {
+	for in, want := range map[string]QueryMode{"": QueryModeMerge, "merge": QueryModeMerge, "hedge": QueryModeHedge, "failover": QueryModeFailover} {
+		if got, err := ParseQueryMode(in); err != nil || got != want {
+			t.Errorf("ParseQueryMode(%q) = %q, %v, expected %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseQueryMode("fastest"); err == nil {
+		t.Error("expected an error for an unknown mode, got nil")
+	}
+}
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConflictPolicy decides what happens when several backends return a series
+// with the same label set
+type ConflictPolicy string
+
+const (
+	// ConflictFirstWins keeps the series from the backend listed first
+	ConflictFirstWins ConflictPolicy = "first"
+
+	// ConflictLatest keeps the series with the most recent sample
+	ConflictLatest ConflictPolicy = "latest"
+
+	// ConflictKeepBoth keeps every copy, told apart by a backend label
+	ConflictKeepBoth ConflictPolicy = "keep-both"
+)
+
+// DefaultBackendLabel is the label added to duplicated series by ConflictKeepBoth
+const DefaultBackendLabel = "__backend__"
+
+// ParseConflictPolicy converts a policy name into a ConflictPolicy
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case ConflictFirstWins, ConflictLatest, ConflictKeepBoth:
+		return p, nil
+	case "":
+		return ConflictFirstWins, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q, expected one of %s, %s or %s", s, ConflictFirstWins, ConflictLatest, ConflictKeepBoth)
+}
+
+// MergeStrategy configures how results from several backends are combined
+type MergeStrategy struct {
+	// Conflict is the policy for duplicate label sets, defaulting to ConflictFirstWins
+	Conflict ConflictPolicy
+
+	// BackendLabel names the label added by ConflictKeepBoth, defaulting to
+	// DefaultBackendLabel
+	BackendLabel string
+
+	// Dedup treats the backends as HA replicas: the replica label is dropped
+	// and copies of the same series are stitched together instead of being
+	// resolved with the conflict policy
+	Dedup bool
+
+	// ReplicaLabel names the label that tells replicas apart, defaulting to
+	// DefaultReplicaLabel
+	ReplicaLabel string
+
+	// stitch joins the copies of a series like Dedup, without dropping the
+	// replica label, for ranges split between backends by time
+	stitch bool
+}
+
+func (s MergeStrategy) conflict() ConflictPolicy {
+	if s.Conflict == "" {
+		return ConflictFirstWins
+	}
+	return s.Conflict
+}
+
+func (s MergeStrategy) backendLabel() string {
+	if s.BackendLabel == "" {
+		return DefaultBackendLabel
+	}
+	return s.BackendLabel
+}
+
+// mergeResults combines backend results, given in backend order, into a single
+// result. Series are matched on their full label set and duplicates are
+// resolved with the strategy's conflict policy
+func mergeResults(results []backendResult, emptyType ResultType, s MergeStrategy) (ResultData, error) {
+	var resultType ResultType
+	for _, r := range results {
+		t := r.Response.Data.Type
+		if t == "" {
+			continue
+		}
+		if resultType == "" {
+			resultType = t
+		} else if t != resultType {
+			return ResultData{}, fmt.Errorf("backends returned mismatched result types %s and %s", resultType, t)
+		}
+	}
+	if resultType == "" {
+		return ResultData{Type: emptyType}, nil
+	}
+
+	merged := ResultData{Type: resultType}
+	switch resultType {
+	case ResultTypeVector:
+		merged.Vector = Vector{}
+		for _, c := range groupSeries(results, vectorEntries, s) {
+			merged.Vector = append(merged.Vector, Sample{Metric: c.metric, Value: c.sample.Value})
+		}
+	case ResultTypeMatrix:
+		merged.Matrix = Matrix{}
+		for _, c := range groupSeries(results, matrixEntries, s) {
+			merged.Matrix = append(merged.Matrix, Series{Metric: c.metric, Values: c.series.Values})
+		}
+	case ResultTypeScalar:
+		merged.Scalar = pickPoint(results, s, func(d ResultData) (*Point, int64) {
+			if d.Scalar == nil {
+				return nil, 0
+			}
+			return d.Scalar, d.Scalar.T
+		})
+	case ResultTypeString:
+		merged.String = pickPoint(results, s, func(d ResultData) (*StringPoint, int64) {
+			if d.String == nil {
+				return nil, 0
+			}
+			return d.String, d.String.T
+		})
+	}
+	return merged, nil
+}
+
+// seriesEntry is one backend's copy of a series, either a sample or a range
+type seriesEntry struct {
+	backend string
+	metric  Labels
+	latest  int64
+	sample  Sample
+	series  Series
+}
+
+func vectorEntries(r backendResult) []seriesEntry {
+	entries := make([]seriesEntry, 0, len(r.Response.Data.Vector))
+	for _, sample := range r.Response.Data.Vector {
+		entries = append(entries, seriesEntry{backend: r.Backend, metric: sample.Metric, latest: sample.Value.T, sample: sample})
+	}
+	return entries
+}
+
+func matrixEntries(r backendResult) []seriesEntry {
+	entries := make([]seriesEntry, 0, len(r.Response.Data.Matrix))
+	for _, series := range r.Response.Data.Matrix {
+		e := seriesEntry{backend: r.Backend, metric: series.Metric, series: series}
+		if n := len(series.Values); n > 0 {
+			e.latest = series.Values[n-1].T
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// groupSeries collects entries by label set in first-seen order and resolves
+// each group into the series that survive the merge, either by deduplicating
+// replicas or by applying the conflict policy
+func groupSeries(results []backendResult, entriesOf func(backendResult) []seriesEntry, s MergeStrategy) []seriesEntry {
+	var order []string
+	groups := make(map[string][]seriesEntry)
+	for _, r := range results {
+		for _, e := range entriesOf(r) {
+			if s.Dedup {
+				e.metric = withoutLabel(e.metric, s.replicaLabel())
+			}
+			key := labelsKey(e.metric)
+			if _, ok := groups[key]; !ok {
+				order = append(order, key)
+			}
+			groups[key] = append(groups[key], e)
+		}
+	}
+
+	out := make([]seriesEntry, 0, len(order))
+	seen := make(map[string]bool, len(order))
+	for _, key := range order {
+		group := groups[key]
+		switch {
+		case s.Dedup || s.stitch:
+			out = append(out, dedupGroup(group))
+		case len(group) == 1 || s.conflict() == ConflictFirstWins:
+			out = append(out, group[0])
+		case s.conflict() == ConflictLatest:
+			best := group[0]
+			for _, e := range group[1:] {
+				if e.latest > best.latest {
+					best = e
+				}
+			}
+			out = append(out, best)
+		case s.conflict() == ConflictKeepBoth:
+			for _, e := range group {
+				e.metric = withLabel(e.metric, s.backendLabel(), e.backend)
+				// the same backend listed twice would produce identical copies
+				if k := labelsKey(e.metric); !seen[k] {
+					seen[k] = true
+					out = append(out, e)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// pickPoint chooses a single scalar or string result. There is no label to
+// tell copies apart, so ConflictKeepBoth behaves like ConflictFirstWins
+func pickPoint[P any](results []backendResult, s MergeStrategy, pointOf func(ResultData) (*P, int64)) *P {
+	var picked *P
+	var pickedT int64
+	for _, r := range results {
+		p, t := pointOf(r.Response.Data)
+		if p == nil {
+			continue
+		}
+		if picked == nil || (s.conflict() == ConflictLatest && t > pickedT) {
+			picked, pickedT = p, t
+		}
+	}
+	return picked
+}
+
+// labelsKey returns a canonical string identifying a label set
+func labelsKey(l Labels) string {
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(0xff)
+		b.WriteString(l[name])
+		b.WriteByte(0xff)
+	}
+	return b.String()
+}
+
+// withLabel returns a copy of l with name set to value
+func withLabel(l Labels, name, value string) Labels {
+	out := make(Labels, len(l)+1)
+	for k, v := range l {
+		out[k] = v
+	}
+	out[name] = value
+	return out
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func vectorResult(backend string, samples ...Sample) backendResult {
+	return backendResult{Backend: backend, Response: &PrometheusResponse{
+		Status: "success",
+		Data:   ResultData{Type: ResultTypeVector, Vector: samples},
+	}}
+}
+
+func TestMergeResults_ConflictPolicies(t *testing.T) {
+	results := []backendResult{
+		vectorResult("a",
+			Sample{Metric: Labels{"job": "node"}, Value: Point{T: 1000, V: 1}},
+			Sample{Metric: Labels{"job": "only-a"}, Value: Point{T: 1000, V: 5}},
+		),
+		vectorResult("b", Sample{Metric: Labels{"job": "node"}, Value: Point{T: 2000, V: 2}}),
+	}
+
+	cases := []struct {
+		policy ConflictPolicy
+		want   Vector
+	}{
+		{ConflictFirstWins, Vector{
+			{Metric: Labels{"job": "node"}, Value: Point{T: 1000, V: 1}},
+			{Metric: Labels{"job": "only-a"}, Value: Point{T: 1000, V: 5}},
+		}},
+		{ConflictLatest, Vector{
+			{Metric: Labels{"job": "node"}, Value: Point{T: 2000, V: 2}},
+			{Metric: Labels{"job": "only-a"}, Value: Point{T: 1000, V: 5}},
+		}},
+		{ConflictKeepBoth, Vector{
+			{Metric: Labels{"job": "node", "src": "a"}, Value: Point{T: 1000, V: 1}},
+			{Metric: Labels{"job": "node", "src": "b"}, Value: Point{T: 2000, V: 2}},
+			{Metric: Labels{"job": "only-a"}, Value: Point{T: 1000, V: 5}},
+		}},
+	}
+	for _, c := range cases {
+		t.Run(string(c.policy), func(t *testing.T) {
+			merged, err := mergeResults(results, ResultTypeVector, MergeStrategy{Conflict: c.policy, BackendLabel: "src"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(merged.Vector, c.want) {
+				t.Errorf("got %+v, want %+v", merged.Vector, c.want)
+			}
+		})
+	}
+}
+
+func TestMergeResults_Matrix(t *testing.T) {
+	results := []backendResult{
+		{Backend: "a", Response: &PrometheusResponse{Data: ResultData{Type: ResultTypeMatrix, Matrix: Matrix{
+			{Metric: Labels{"job": "node"}, Values: []Point{{T: 1000, V: 1}}},
+		}}}},
+		{Backend: "b", Response: &PrometheusResponse{Data: ResultData{Type: ResultTypeMatrix, Matrix: Matrix{
+			{Metric: Labels{"job": "node"}, Values: []Point{{T: 1000, V: 1}, {T: 2000, V: 2}}},
+			{Metric: Labels{"job": "other"}, Values: []Point{{T: 1000, V: 3}}},
+		}}}},
+	}
+	merged, err := mergeResults(results, ResultTypeMatrix, MergeStrategy{Conflict: ConflictLatest})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged.Matrix) != 2 || len(merged.Matrix[0].Values) != 2 {
+		t.Errorf("expected the longer node series and the other series, got %+v", merged.Matrix)
+	}
+}
+
+func TestMergeResults_MismatchedTypes(t *testing.T) {
+	results := []backendResult{
+		vectorResult("a"),
+		{Backend: "b", Response: &PrometheusResponse{Data: ResultData{Type: ResultTypeScalar, Scalar: &Point{}}}},
+	}
+	if _, err := mergeResults(results, ResultTypeVector, MergeStrategy{}); err == nil {
+		t.Error("expected error for mismatched result types, got nil")
+	}
+}
+
+func TestParseConflictPolicy(t *testing.T) {
+	if p, err := ParseConflictPolicy(""); err != nil || p != ConflictFirstWins {
+		t.Errorf("expected default policy, got %q, %v", p, err)
+	}
+	if p, err := ParseConflictPolicy("keep-both"); err != nil || p != ConflictKeepBoth {
+		t.Errorf("expected keep-both, got %q, %v", p, err)
+	}
+	if _, err := ParseConflictPolicy("random"); err == nil {
+		t.Error("expected error for unknown policy, got nil")
+	}
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// MetadataQuery selects the series that the series, label names and label
+// values endpoints report on
+type MetadataQuery struct {
+	// Matchers are series selectors such as up{job="node"}, sent as match[]
+	Matchers []string
+
+	// Start and End bound the time range searched, unset means the backend default
+	Start time.Time
+	End   time.Time
+
+	// Backends names the backends to ask, defaulting to the client's backends
+	Backends []string
+
+	// PartialResponse, Timeout and BackendTimeout behave as in QueryData
+	PartialResponse PartialResponseStrategy
+	Timeout         time.Duration
+	BackendTimeout  time.Duration
+}
+
+// SeriesResponse is the union of the series found on every backend
+type SeriesResponse struct {
+	Status   string          `json:"status"`
+	Data     []Labels        `json:"data"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Backends []BackendStatus `json:"backends,omitempty"`
+}
+
+// LabelsResponse is the union of the label names or values found on every backend
+type LabelsResponse struct {
+	Status   string          `json:"status"`
+	Data     []string        `json:"data"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Backends []BackendStatus `json:"backends,omitempty"`
+}
+
+// Series lists the series matching q on every backend, deduplicated by label set
+func (c *Client) Series(ctx context.Context, q MetadataQuery) (*SeriesResponse, error) {
+	outcomes, statuses, warnings, err := fanOutMetadata[[]Labels](ctx, c, q, "/api/v1/series", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp := &SeriesResponse{Status: "success", Data: []Labels{}, Warnings: warnings, Backends: statuses}
+	seen := make(map[string]bool)
+	for _, o := range outcomes {
+		for _, series := range o.Response {
+			key := labelsKey(series)
+			if !seen[key] {
+				seen[key] = true
+				resp.Data = append(resp.Data, series)
+			}
+		}
+	}
+	sort.Slice(resp.Data, func(i, j int) bool {
+		return resp.Data[i].String() < resp.Data[j].String()
+	})
+	return resp, nil
+}
+
+// LabelNames lists the label names used by series matching q on every backend
+func (c *Client) LabelNames(ctx context.Context, q MetadataQuery) (*LabelsResponse, error) {
+	return c.labels(ctx, q, "/api/v1/labels")
+}
+
+// LabelValues lists the values of the label name on series matching q on
+// every backend
+func (c *Client) LabelValues(ctx context.Context, name string, q MetadataQuery) (*LabelsResponse, error) {
+	return c.labels(ctx, q, "/api/v1/label/"+url.PathEscape(name)+"/values")
+}
+
+func (c *Client) labels(ctx context.Context, q MetadataQuery, path string) (*LabelsResponse, error) {
+	outcomes, statuses, warnings, err := fanOutMetadata[[]string](ctx, c, q, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp := &LabelsResponse{Status: "success", Data: []string{}, Warnings: warnings, Backends: statuses}
+	for _, o := range outcomes {
+		resp.Data = appendUnique(resp.Data, o.Response...)
+	}
+	sort.Strings(resp.Data)
+	return resp, nil
+}
+
+// fanOutMetadata sends a metadata request with the query's matchers and time
+// range plus any extra params to every backend and decodes the data of each
+// response as T
+func fanOutMetadata[T any](ctx context.Context, c *Client, q MetadataQuery, path string, extra url.Values) ([]outcome[T], []BackendStatus, []string, error) {
+	if q.PartialResponse == "" {
+		q.PartialResponse = c.partialResponse
+	}
+	if q.Timeout == 0 {
+		q.Timeout = c.timeout
+	}
+	if q.BackendTimeout == 0 {
+		q.BackendTimeout = c.backendTimeout
+	}
+	if q.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.Timeout)
+		defer cancel()
+	}
+
+	call := func(ctx context.Context, b Backend) (T, []string, error) {
+		var data T
+		params := url.Values{}
+		for k, vs := range extra {
+			params[k] = append([]string(nil), vs...)
+		}
+		for _, m := range q.Matchers {
+			params.Add("match[]", m)
+		}
+		if !q.Start.IsZero() {
+			params.Set("start", formatTime(q.Start))
+		}
+		if !q.End.IsZero() {
+			params.Set("end", formatTime(q.End))
+		}
+		envelope, err := c.callAPI(ctx, b, path, params)
+		if err != nil {
+			return data, nil, err
+		}
+		if err := json.Unmarshal(envelope.Data, &data); err != nil {
+			return data, nil, err
+		}
+		return data, envelope.Warnings, nil
+	}
+	return settle(fanOut(ctx, c, c.backendsFor(q.Backends), q.BackendTimeout, call), q.PartialResponse)
+}
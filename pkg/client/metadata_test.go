@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// mockMetadataHandler serves fixed series, label names and job label values
+func mockMetadataHandler(t *testing.T, series []Labels, names, jobs []string) http.HandlerFunc {
+	t.Helper()
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		var data any
+		switch r.URL.Path {
+		case "/api/v1/series":
+			if len(r.Form["match[]"]) == 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data = series
+		case "/api/v1/labels":
+			data = names
+		case "/api/v1/label/job/values":
+			data = jobs
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]any{"status": "success", "data": data}); err != nil {
+			t.Errorf("failed to encode response: %v", err)
+		}
+	}
+}
+
+func TestClient_MetadataUnion(t *testing.T) {
+	params := make(chan string, 2)
+	a := httptest.NewServer(mockMetadataHandler(t,
+		[]Labels{{"__name__": "up", "job": "a"}, {"__name__": "up", "job": "shared"}},
+		[]string{"__name__", "job"},
+		[]string{"a", "shared"},
+	))
+	defer a.Close()
+	b := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params <- r.URL.RawQuery
+		mockMetadataHandler(t,
+			[]Labels{{"__name__": "up", "job": "shared"}, {"__name__": "up", "job": "b"}},
+			[]string{"instance", "job"},
+			[]string{"b", "shared"},
+		)(w, r)
+	}))
+	defer b.Close()
+
+	c, err := New(WithBackends(Backend{URL: a.URL}, Backend{URL: b.URL}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := MetadataQuery{Matchers: []string{`up{job=~".+"}`}, Start: time.Unix(1700000000, 0), End: time.Unix(1700003600, 0)}
+
+	series, err := c.Series(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantSeries := []Labels{{"__name__": "up", "job": "a"}, {"__name__": "up", "job": "b"}, {"__name__": "up", "job": "shared"}}
+	if !reflect.DeepEqual(series.Data, wantSeries) {
+		t.Errorf("got series %v, want %v", series.Data, wantSeries)
+	}
+	if got := <-params; got != "end=1700003600&match%5B%5D=up%7Bjob%3D~%22.%2B%22%7D&start=1700000000" {
+		t.Errorf("unexpected series parameters %s", got)
+	}
+
+	names, err := c.LabelNames(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-params
+	if want := []string{"__name__", "instance", "job"}; !reflect.DeepEqual(names.Data, want) {
+		t.Errorf("got label names %v, want %v", names.Data, want)
+	}
+
+	values, err := c.LabelValues(context.Background(), "job", MetadataQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"a", "b", "shared"}; !reflect.DeepEqual(values.Data, want) {
+		t.Errorf("got label values %v, want %v", values.Data, want)
+	}
+	if len(values.Backends) != 2 {
+		t.Errorf("expected a status per backend, got %+v", values.Backends)
+	}
+}
+
+func TestClient_SeriesPartialResponse(t *testing.T) {
+	ts := httptest.NewServer(mockMetadataHandler(t, []Labels{{"job": "a"}}, nil, nil))
+	defer ts.Close()
+
+	c, err := New(WithBackends(Backend{URL: ts.URL}, Backend{URL: "http://invalid:9999"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := c.Series(context.Background(), MetadataQuery{Matchers: []string{"up"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Data) != 1 || len(resp.Warnings) != 1 {
+		t.Errorf("expected the reachable backend's series and a warning, got %+v", resp)
+	}
+
+	if _, err := c.Series(context.Background(), MetadataQuery{Matchers: []string{"up"}, PartialResponse: PartialResponseStrict}); err == nil {
+		t.Error("expected strict mode to fail, got nil")
+	}
+}
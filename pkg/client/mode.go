@@ -0,0 +1,36 @@
+package client
+
+import "fmt"
+
+// QueryMode decides how a query uses the backends it is sent to
+type QueryMode string
+
+const (
+	// QueryModeMerge sends the query to every backend and merges the results
+	QueryModeMerge QueryMode = "merge"
+
+	// QueryModeHedge treats the backends as replicas of each other. The query
+	// is sent to one of them and hedged to the next one when it is slow, and
+	// the first successful answer is returned
+	QueryModeHedge QueryMode = "hedge"
+
+	// QueryModeFailover sends the query to the backends one at a time in
+	// priority order, highest Weight first, and returns the answer of the
+	// first one that does not fail
+	QueryModeFailover QueryMode = "failover"
+
+	// QueryModeQuorum sends the query to every backend and requires a number
+	// of them to return the same result, which is returned unmerged
+	QueryModeQuorum QueryMode = "quorum"
+)
+
+// ParseQueryMode converts a mode name into a QueryMode
+func ParseQueryMode(s string) (QueryMode, error) {
+	switch m := QueryMode(s); m {
+	case QueryModeMerge, QueryModeHedge, QueryModeFailover, QueryModeQuorum:
+		return m, nil
+	case "":
+		return QueryModeMerge, nil
+	}
+	return "", fmt.Errorf("unknown query mode %q, expected %s, %s, %s or %s", s, QueryModeMerge, QueryModeHedge, QueryModeFailover, QueryModeQuorum)
+}
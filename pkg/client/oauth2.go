@@ -0,0 +1,141 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2ExpiryDelta is how long before its expiry a token is refreshed, so
+// that it does not expire while a request is in flight
+const oauth2ExpiryDelta = 10 * time.Second
+
+// OAuth2 configures the OAuth2 client credentials grant used to obtain the
+// bearer tokens of a backend
+type OAuth2 struct {
+	ClientID string `yaml:"client_id"`
+
+	// ClientSecret is used as is, ClientSecretFile is read on every token fetch
+	ClientSecret     string `yaml:"client_secret"`
+	ClientSecretFile string `yaml:"client_secret_file"`
+
+	// TokenURL is the token endpoint of the authorization server
+	TokenURL string `yaml:"token_url"`
+
+	// Scopes and EndpointParams are added to the token request
+	Scopes         []string          `yaml:"scopes"`
+	EndpointParams map[string]string `yaml:"endpoint_params"`
+}
+
+func (o *OAuth2) validate() error {
+	if o.ClientID == "" || o.TokenURL == "" {
+		return errors.New("OAuth2 needs a client_id and a token_url")
+	}
+	if o.ClientSecret != "" && o.ClientSecretFile != "" {
+		return errors.New("OAuth2 client_secret and client_secret_file are mutually exclusive")
+	}
+	return nil
+}
+
+// oauth2TokenSource authenticates requests with a cached access token,
+// fetching a new one when the cached one is about to expire
+type oauth2TokenSource struct {
+	config *OAuth2
+	client *http.Client
+	now    func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func newOAuth2TokenSource(config *OAuth2, client *http.Client) *oauth2TokenSource {
+	return &oauth2TokenSource{config: config, client: client, now: time.Now}
+}
+
+// Authenticate sets the Authorization header to a valid access token
+func (s *oauth2TokenSource) Authenticate(req *http.Request) error {
+	// concurrent requests wait for a single token fetch
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == "" || (!s.expiry.IsZero() && !s.now().Add(oauth2ExpiryDelta).Before(s.expiry)) {
+		if err := s.refresh(req); err != nil {
+			return fmt.Errorf("fetching OAuth2 token: %w", err)
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	return nil
+}
+
+// oauth2Token is the token endpoint response of RFC 6749 section 5.1
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// refresh fetches a new token, bound to the context of the request that
+// needs it
+func (s *oauth2TokenSource) refresh(req *http.Request) error {
+	secret := s.config.ClientSecret
+	if s.config.ClientSecretFile != "" {
+		var err error
+		if secret, err = readSecretFile(s.config.ClientSecretFile); err != nil {
+			return err
+		}
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	for k, v := range s.config.EndpointParams {
+		form.Set(k, v)
+	}
+	tokenReq, err := http.NewRequestWithContext(req.Context(), http.MethodPost, s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	tokenReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// RFC 6749 section 2.3.1 form-encodes the credentials before basic auth
+	tokenReq.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(secret))
+
+	fetchedAt := s.now()
+	resp, err := s.client.Do(tokenReq)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return newAPIError(resp.StatusCode, body, nil)
+	}
+	var token oauth2Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return err
+	}
+	if token.AccessToken == "" {
+		return errors.New("token endpoint returned no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return fmt.Errorf("unsupported token type %q", token.TokenType)
+	}
+
+	s.token, s.expiry = token.AccessToken, time.Time{}
+	if token.ExpiresIn > 0 {
+		s.expiry = fetchedAt.Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return nil
+}
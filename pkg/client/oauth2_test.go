@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTokenServer stands in for an authorization server, issuing numbered
+// tokens that expire after expiresIn seconds
+func newTokenServer(t *testing.T, expiresIn int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var issued atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("scope") != "read metrics" || r.Form.Get("audience") != "prometheus" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, n, expiresIn); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	return ts, &issued
+}
+
+func testOAuth2Config(tokenURL string) *OAuth2 {
+	return &OAuth2{
+		ClientID:       "client",
+		ClientSecret:   "s3cret",
+		TokenURL:       tokenURL,
+		Scopes:         []string{"read", "metrics"},
+		EndpointParams: map[string]string{"audience": "prometheus"},
+	}
+}
+
+func TestClient_OAuth2(t *testing.T) {
+	tokenServer, issued := newTokenServer(t, 3600)
+	defer tokenServer.Close()
+	auth := make(chan string, 1)
+	backend := httptest.NewServer(authHandler(t, auth))
+	defer backend.Close()
+
+	c, err := New(WithBackends(Backend{Name: "managed", URL: backend.URL, OAuth2: testOAuth2Config(tokenServer.URL)}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := c.QueryPrometheus(context.Background(), "managed", "up"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := <-auth; got != "Bearer token-1" {
+			t.Errorf("request %d: expected cached token, got %q", i, got)
+		}
+	}
+	if n := issued.Load(); n != 1 {
+		t.Errorf("expected a single token fetch, got %d", n)
+	}
+}
+
+func TestOAuth2TokenSource_Refresh(t *testing.T) {
+	tokenServer, issued := newTokenServer(t, 60)
+	defer tokenServer.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := newOAuth2TokenSource(testOAuth2Config(tokenServer.URL), http.DefaultClient)
+	source.now = func() time.Time { return now }
+	authenticate := func() string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "http://backend/api/v1/query", nil)
+		if err := source.Authenticate(req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return req.Header.Get("Authorization")
+	}
+
+	if got := authenticate(); got != "Bearer token-1" {
+		t.Errorf("unexpected token %q", got)
+	}
+	now = now.Add(45 * time.Second)
+	if got := authenticate(); got != "Bearer token-1" {
+		t.Errorf("expected token to be reused before expiry, got %q", got)
+	}
+	// within the expiry delta the token is refreshed early
+	now = now.Add(10 * time.Second)
+	if got := authenticate(); got != "Bearer token-2" {
+		t.Errorf("expected refreshed token, got %q", got)
+	}
+	if n := issued.Load(); n != 2 {
+		t.Errorf("expected two token fetches, got %d", n)
+	}
+}
+
+func TestClient_OAuth2Failure(t *testing.T) {
+	tokenServer, _ := newTokenServer(t, 60)
+	defer tokenServer.Close()
+
+	config := testOAuth2Config(tokenServer.URL)
+	config.ClientSecret = "wrong"
+	c, err := New(WithBackends(Backend{Name: "managed", URL: "http://backend.invalid", OAuth2: config}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.QueryPrometheus(context.Background(), "managed", "up"); err == nil {
+		t.Error("expected token fetch to fail, got nil")
+	}
+
+	if _, err := New(WithBackends(Backend{URL: "http://backend", OAuth2: &OAuth2{ClientID: "client"}})); err == nil {
+		t.Error("expected error for OAuth2 without token_url, got nil")
+	}
+	if _, err := New(WithBackends(Backend{URL: "http://backend", OAuth2: config, SigV4: &SigV4{Region: "us-east-1"}})); err == nil {
+		t.Error("expected error for several authenticators, got nil")
+	}
+}
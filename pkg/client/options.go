@@ -0,0 +1,290 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cortex-client/pkg/ratelimiter"
+)
+
+const (
+	// DefaultWorkers is the number of backends queried concurrently
+	DefaultWorkers = 5
+
+	// DefaultRateLimit is the number of requests the default rate limiter
+	// allows in flight at once
+	DefaultRateLimit = 100
+
+	// DefaultTokenResetsAfter is how long the default rate limiter lets a
+	// token live before forcefully releasing it
+	DefaultTokenResetsAfter = 10 * time.Second
+
+	// MaxGETParamsLength is the longest encoded parameter string that
+	// RequestMethodAuto sends in a GET URL before switching to POST
+	MaxGETParamsLength = 4096
+)
+
+// RequestMethod selects how API parameters are sent to backends
+type RequestMethod string
+
+const (
+	// RequestMethodAuto uses GET and switches to POST for long parameters
+	RequestMethodAuto RequestMethod = "auto"
+
+	// RequestMethodGET always puts the parameters in the URL
+	RequestMethodGET RequestMethod = "get"
+
+	// RequestMethodPOST always sends a form-encoded body
+	RequestMethodPOST RequestMethod = "post"
+)
+
+// ParseRequestMethod converts a method name into a RequestMethod
+func ParseRequestMethod(s string) (RequestMethod, error) {
+	switch m := RequestMethod(strings.ToLower(s)); m {
+	case RequestMethodAuto, RequestMethodGET, RequestMethodPOST:
+		return m, nil
+	case "":
+		return RequestMethodAuto, nil
+	}
+	return "", fmt.Errorf("unknown request method %q, expected %s, %s or %s", s, RequestMethodAuto, RequestMethodGET, RequestMethodPOST)
+}
+
+func (m RequestMethod) usePOST(paramsLength int) bool {
+	switch m {
+	case RequestMethodPOST:
+		return true
+	case RequestMethodGET:
+		return false
+	}
+	return paramsLength > MaxGETParamsLength
+}
+
+// Option configures a Client
+type Option func(*Client) error
+
+// WithHTTPClient sets the HTTP client used for every backend request
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) error {
+		if httpClient == nil {
+			return errors.New("http client must not be nil")
+		}
+		c.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithTransport sets the transport of the client's HTTP client, leaving any
+// other settings of a client given with WithHTTPClient in place
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) error {
+		if transport == nil {
+			return errors.New("transport must not be nil")
+		}
+		httpClient := *c.httpClient
+		httpClient.Transport = transport
+		c.httpClient = &httpClient
+		return nil
+	}
+}
+
+// WithWorkers sets how many backends are queried concurrently
+func WithWorkers(workers int) Option {
+	return func(c *Client) error {
+		if workers <= 0 {
+			return fmt.Errorf("worker count must be greater than zero, got %d", workers)
+		}
+		c.workers = workers
+		return nil
+	}
+}
+
+// WithRateLimiter sets the rate limiter shared by every backend request
+func WithRateLimiter(limiter ratelimiter.RateLimiter) Option {
+	return func(c *Client) error {
+		if limiter == nil {
+			return errors.New("rate limiter must not be nil")
+		}
+		c.limiter = limiter
+		return nil
+	}
+}
+
+// WithLogger sets the logger for rate limiting and backend errors
+func WithLogger(logger *log.Logger) Option {
+	return func(c *Client) error {
+		if logger == nil {
+			return errors.New("logger must not be nil")
+		}
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithBackends sets the backends queried when a query names none
+func WithBackends(backends ...Backend) Option {
+	return func(c *Client) error {
+		for _, b := range backends {
+			if err := b.validate(); err != nil {
+				return err
+			}
+		}
+		c.backends = append(c.backends, backends...)
+		return nil
+	}
+}
+
+// WithRetryPolicy sets how failed backend requests are retried, replacing
+// DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) error {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+		c.retry = policy
+		return nil
+	}
+}
+
+// WithCircuitBreaker configures the circuit breakers kept for each backend
+// URL, replacing DefaultBreakerConfig. A zero FailureThreshold disables them
+func WithCircuitBreaker(config BreakerConfig) Option {
+	return func(c *Client) error {
+		if err := config.validate(); err != nil {
+			return err
+		}
+		c.breakerConfig = config
+		return nil
+	}
+}
+
+// WithHedgePolicy sets when hedged queries move on to the next replica,
+// replacing DefaultHedgePolicy
+func WithHedgePolicy(policy HedgePolicy) Option {
+	return func(c *Client) error {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+		c.hedge = policy
+		return nil
+	}
+}
+
+// WithMergeStrategy sets the merge strategy used by queries that do not set one
+func WithMergeStrategy(strategy MergeStrategy) Option {
+	return func(c *Client) error {
+		c.merge = strategy
+		return nil
+	}
+}
+
+// WithPartialResponse sets the partial response strategy used by queries
+// that do not set one
+func WithPartialResponse(strategy PartialResponseStrategy) Option {
+	return func(c *Client) error {
+		c.partialResponse = strategy
+		return nil
+	}
+}
+
+// WithTimeout sets the default deadline for a whole merged query
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// WithBackendTimeout sets the default deadline for each backend request
+func WithBackendTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		c.backendTimeout = timeout
+		return nil
+	}
+}
+
+// WithRequestMethod sets whether API parameters are sent with GET or POST
+func WithRequestMethod(method RequestMethod) Option {
+	return func(c *Client) error {
+		if _, err := ParseRequestMethod(string(method)); err != nil {
+			return err
+		}
+		c.method = method
+		return nil
+	}
+}
+
+// WithGroup defines a named group of configured backends. A query that
+// references the group is sent to each of its members
+func WithGroup(name string, backends ...string) Option {
+	return func(c *Client) error {
+		if name == "" || len(backends) == 0 {
+			return errors.New("group needs a name and at least one backend")
+		}
+		if c.groups == nil {
+			c.groups = make(map[string][]string)
+		}
+		c.groups[name] = backends
+		return nil
+	}
+}
+
+// WithConfig applies a configuration file: its global settings, backends and
+// groups. Options given after it override the global settings
+func WithConfig(cfg *Config) Option {
+	return func(c *Client) error {
+		g := cfg.Global
+		if g.Workers > 0 {
+			c.workers = g.Workers
+		}
+		if g.Limiter != nil {
+			limiter, err := g.Limiter.build()
+			if err != nil {
+				return err
+			}
+			c.limiter = limiter
+		}
+		if g.Retry != nil {
+			if err := WithRetryPolicy(*g.Retry)(c); err != nil {
+				return err
+			}
+		}
+		if g.CircuitBreaker != nil {
+			if err := WithCircuitBreaker(*g.CircuitBreaker)(c); err != nil {
+				return err
+			}
+		}
+		if g.Hedge != nil {
+			if err := WithHedgePolicy(*g.Hedge)(c); err != nil {
+				return err
+			}
+		}
+		if g.Timeout > 0 {
+			c.timeout = g.Timeout
+		}
+		if g.BackendTimeout > 0 {
+			c.backendTimeout = g.BackendTimeout
+		}
+		if g.PartialResponse != "" {
+			c.partialResponse = g.PartialResponse
+		}
+		for _, bc := range cfg.Backends {
+			b, err := bc.Backend()
+			if err != nil {
+				return err
+			}
+			if err := WithBackends(b)(c); err != nil {
+				return err
+			}
+		}
+		for name, members := range cfg.Groups {
+			if err := WithGroup(name, members...)(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
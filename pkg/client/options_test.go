@@ -0,0 +1,179 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cortex-client/pkg/ratelimiter"
+)
+
+type countingTransport struct {
+	requests atomic.Int64
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	cases := map[string]Option{
+		"zero workers":     WithWorkers(0),
+		"nil http client":  WithHTTPClient(nil),
+		"nil transport":    WithTransport(nil),
+		"nil rate limiter": WithRateLimiter(nil),
+		"nil logger":       WithLogger(nil),
+		"backend no URL":   WithBackends(Backend{Name: "a"}),
+	}
+	for name, opt := range cases {
+		if _, err := New(opt); err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+	}
+}
+
+func TestClient_UsesConfiguredBackendsAndTransport(t *testing.T) {
+	ts := httptest.NewServer(mockPrometheusHandler(t))
+	defer ts.Close()
+
+	transport := &countingTransport{}
+	var logs bytes.Buffer
+	c, err := New(
+		WithTransport(transport),
+		WithLogger(log.New(&logs, "", 0)),
+		WithBackends(Backend{Name: "primary", URL: ts.URL}, Backend{Name: "secondary", URL: ts.URL}),
+		WithWorkers(1),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	merged, err := c.Query(context.Background(), QueryData{Query: "up"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged.Backends) != 2 || merged.Backends[0].Backend != "primary" || merged.Backends[1].Backend != "secondary" {
+		t.Errorf("expected both configured backends by name, got %+v", merged.Backends)
+	}
+	if transport.requests.Load() != 2 {
+		t.Errorf("expected 2 requests through the transport, got %d", transport.requests.Load())
+	}
+	if logs.Len() == 0 {
+		t.Error("expected rate limiter activity on the configured logger")
+	}
+
+	merged, err = c.Query(context.Background(), QueryData{Query: "up", Backends: []string{"secondary"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged.Backends) != 1 || merged.Backends[0].Backend != "secondary" {
+		t.Errorf("expected a backend referenced by name to resolve, got %+v", merged.Backends)
+	}
+}
+
+func TestClient_ReusedConcurrently(t *testing.T) {
+	ts := httptest.NewServer(mockPrometheusHandler(t))
+	defer ts.Close()
+
+	// A limit of one only works across many queries if every token is released
+	limiter, err := ratelimiter.NewMaxConcurrencyRateLimiter(&ratelimiter.Config{Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := New(
+		WithRateLimiter(limiter),
+		WithLogger(log.New(&bytes.Buffer{}, "", 0)),
+		WithBackends(Backend{URL: ts.URL}, Backend{URL: ts.URL}),
+		WithTimeout(5*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Query(context.Background(), QueryData{Query: "up"}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestClient_EncodesQueriesAndSwitchesToPOST(t *testing.T) {
+	type received struct {
+		method, query, contentType, ts string
+	}
+	requests := make(chan received, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		requests <- received{r.Method, r.Form.Get("query"), r.Header.Get("Content-Type"), r.Form.Get("time")}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	query := `sum by (job) (rate(http_requests_total{code=~"5..", path!="/a&b"}[5m])) + 1`
+	long := query + strings.Repeat(" + 1", MaxGETParamsLength)
+	evalTime := time.Unix(1700000000, 0)
+
+	cases := []struct {
+		name   string
+		method RequestMethod
+		query  string
+		want   string
+	}{
+		{"auto short query uses GET", RequestMethodAuto, query, http.MethodGet},
+		{"auto long query uses POST", RequestMethodAuto, long, http.MethodPost},
+		{"forced POST", RequestMethodPOST, query, http.MethodPost},
+		{"forced GET", RequestMethodGET, long, http.MethodGet},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New(WithRequestMethod(tc.method))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := c.QueryPrometheusAt(context.Background(), ts.URL, tc.query, evalTime); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := <-requests
+			if got.method != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got.method)
+			}
+			if got.query != tc.query {
+				t.Errorf("query was corrupted in transit: got %q", got.query)
+			}
+			if got.method == http.MethodPost && got.contentType != "application/x-www-form-urlencoded" {
+				t.Errorf("unexpected content type %q", got.contentType)
+			}
+			if got.ts != "1700000000" {
+				t.Errorf("expected explicit evaluation time, got %q", got.ts)
+			}
+		})
+	}
+}
+
+func TestParseRequestMethod(t *testing.T) {
+	if m, err := ParseRequestMethod("POST"); err != nil || m != RequestMethodPOST {
+		t.Errorf("expected post, got %q, %v", m, err)
+	}
+	if _, err := ParseRequestMethod("put"); err == nil {
+		t.Error("expected error for unknown method, got nil")
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PartialResponseStrategy decides what a merged query does when some backends fail
+type PartialResponseStrategy string
+
+const (
+	// PartialResponseLenient returns the merged result of the backends that
+	// succeeded, with a warning for each one that failed
+	PartialResponseLenient PartialResponseStrategy = "lenient"
+
+	// PartialResponseStrict fails the whole query when any backend fails
+	PartialResponseStrict PartialResponseStrategy = "strict"
+)
+
+// ParsePartialResponseStrategy converts a strategy name into a PartialResponseStrategy
+func ParsePartialResponseStrategy(s string) (PartialResponseStrategy, error) {
+	switch p := PartialResponseStrategy(s); p {
+	case PartialResponseLenient, PartialResponseStrict:
+		return p, nil
+	case "":
+		return PartialResponseLenient, nil
+	}
+	return "", fmt.Errorf("unknown partial response strategy %q, expected %s or %s", s, PartialResponseLenient, PartialResponseStrict)
+}
+
+const (
+	BackendStatusSuccess = "success"
+	BackendStatusError   = "error"
+
+	// BackendStatusSkipped is a backend that was not queried because its
+	// circuit breaker is open or its last health check found it down
+	BackendStatusSkipped = "skipped"
+
+	// BackendStatusCancelled is a hedged request that was cancelled because
+	// another replica answered first
+	BackendStatusCancelled = "cancelled"
+)
+
+// BackendStatus is the outcome of a query against a single backend
+type BackendStatus struct {
+	Backend    string        `json:"backend"`
+	Status     string        `json:"status"`
+	HTTPStatus int           `json:"httpStatus,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Latency    time.Duration `json:"latency"`
+	Warnings   []string      `json:"warnings,omitempty"`
+
+	// Attempts is the number of requests sent, more than one when retried
+	Attempts int `json:"attempts,omitempty"`
+
+	// Health is the backend's state at its last health check, if any
+	Health HealthState `json:"health,omitempty"`
+}
+
+type backendStatusJSON struct {
+	Backend    string   `json:"backend"`
+	Status     string   `json:"status"`
+	HTTPStatus int      `json:"httpStatus,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	Latency    string   `json:"latency"`
+	Warnings   []string `json:"warnings,omitempty"`
+	Attempts   int      `json:"attempts,omitempty"`
+	Health     string   `json:"health,omitempty"`
+}
+
+// MarshalJSON encodes the latency as a human-readable duration
+func (s BackendStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(backendStatusJSON{
+		Backend:    s.Backend,
+		Status:     s.Status,
+		HTTPStatus: s.HTTPStatus,
+		Error:      s.Error,
+		Latency:    s.Latency.String(),
+		Warnings:   s.Warnings,
+		Attempts:   s.Attempts,
+		Health:     string(s.Health),
+	})
+}
+
+// UnmarshalJSON decodes a status encoded by MarshalJSON
+func (s *BackendStatus) UnmarshalJSON(b []byte) error {
+	var raw backendStatusJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	latency, err := time.ParseDuration(raw.Latency)
+	if err != nil && raw.Latency != "" {
+		return fmt.Errorf("invalid backend latency %q: %w", raw.Latency, err)
+	}
+	*s = BackendStatus{
+		Backend:    raw.Backend,
+		Status:     raw.Status,
+		HTTPStatus: raw.HTTPStatus,
+		Error:      raw.Error,
+		Latency:    latency,
+		Warnings:   raw.Warnings,
+		Attempts:   raw.Attempts,
+		Health:     HealthState(raw.Health),
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QuorumReport describes how the backends of a quorum query agreed
+type QuorumReport struct {
+	// Required is the number of backends that had to agree
+	Required int `json:"required"`
+
+	// Agreeing are the backends that returned the result, within the
+	// tolerance of the first of them
+	Agreeing []string `json:"agreeing"`
+
+	// Disagreements lists how every other successful backend differs from
+	// the first agreeing one
+	Disagreements []Disagreement `json:"disagreements,omitempty"`
+}
+
+// Disagreement is a backend whose result differs from the reference result
+type Disagreement struct {
+	Backend     string       `json:"backend"`
+	Reference   string       `json:"reference"`
+	Differences []Difference `json:"differences"`
+}
+
+// QuorumError is returned when fewer backends than required agree on a result
+type QuorumError struct {
+	Report QuorumReport
+}
+
+func (e *QuorumError) Error() string {
+	var disagreeing []string
+	for _, d := range e.Report.Disagreements {
+		disagreeing = append(disagreeing, d.Backend)
+	}
+	msg := fmt.Sprintf("quorum not reached: %d backends agree, %d required", len(e.Report.Agreeing), e.Report.Required)
+	if len(disagreeing) > 0 {
+		msg += ", disagreeing: " + strings.Join(disagreeing, ", ")
+	}
+	return msg
+}
+
+// defaultQuorum is a majority of the backends
+func defaultQuorum(backends int) int {
+	return backends/2 + 1
+}
+
+// checkQuorum groups the successful results by agreement, each group holding
+// the results that match its first one within tol, and picks the largest
+// group, the earliest on ties. Tolerance is not transitive, so every result
+// is then compared with that group's first one: the ones matching it agree,
+// and at least required results must. The returned report lists how the
+// others differ
+func checkQuorum(results []backendResult, required int, tol Tolerance) ([]backendResult, QuorumReport, error) {
+	// groups hold indexes into results
+	var groups [][]int
+	for i, r := range results {
+		placed := false
+		for j, g := range groups {
+			if len(compareResults(results[g[0]].Response.Data, r.Response.Data, tol)) == 0 {
+				groups[j] = append(g, i)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			groups = append(groups, []int{i})
+		}
+	}
+
+	report := QuorumReport{Required: required, Agreeing: []string{}}
+	best := -1
+	for i, g := range groups {
+		if best < 0 || len(g) > len(groups[best]) {
+			best = i
+		}
+	}
+	var agreeing []backendResult
+	if best >= 0 {
+		refIndex := groups[best][0]
+		ref := results[refIndex]
+		agreeing = append(agreeing, ref)
+		for i, r := range results {
+			if i == refIndex {
+				continue
+			}
+			diffs := compareResults(ref.Response.Data, r.Response.Data, tol)
+			if len(diffs) == 0 {
+				agreeing = append(agreeing, r)
+				continue
+			}
+			report.Disagreements = append(report.Disagreements, Disagreement{Backend: r.Backend, Reference: ref.Backend, Differences: diffs})
+		}
+	}
+	for _, r := range agreeing {
+		report.Agreeing = append(report.Agreeing, r.Backend)
+	}
+	if len(agreeing) < required {
+		return nil, report, &QuorumError{Report: report}
+	}
+	return agreeing, report, nil
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// vectorServer answers every query with a single sample of value
+func vectorServer(t *testing.T, value string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body := fmt.Sprintf(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"node"},"value":[1700000000,%q]}]}}`, value)
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+}
+
+func TestClientQuery_Quorum(t *testing.T) {
+	a := vectorServer(t, "1")
+	defer a.Close()
+	b := vectorServer(t, "1.05")
+	defer b.Close()
+	c := vectorServer(t, "3")
+	defer c.Close()
+
+	cl, err := New(WithBackends(Backend{Name: "a", URL: a.URL}, Backend{Name: "b", URL: b.URL}, Backend{Name: "c", URL: c.URL}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	merged, err := cl.Query(context.Background(), QueryData{Query: "up", Mode: QueryModeQuorum, Tolerance: Tolerance{Absolute: 0.1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merged.AnsweredBy != "a" || len(merged.Data.Vector) != 1 || merged.Data.Vector[0].Value.V != 1 {
+		t.Errorf("expected the result of a, got %q and %+v", merged.AnsweredBy, merged.Data)
+	}
+	if r := merged.Quorum; r == nil || r.Required != 2 || len(r.Agreeing) != 2 || len(r.Disagreements) != 1 || r.Disagreements[0].Backend != "c" {
+		t.Errorf("unexpected quorum report %+v", merged.Quorum)
+	}
+	if len(merged.Warnings) != 1 || merged.Warnings[0] != `backend c disagrees with backend a: value of {job="node"} at 2023-11-14T22:13:20Z is 3, expected 1` {
+		t.Errorf("unexpected warnings %v", merged.Warnings)
+	}
+
+	_, err = cl.Query(context.Background(), QueryData{Query: "up", Mode: QueryModeQuorum})
+	var quorumErr *QuorumError
+	if !errors.As(err, &quorumErr) {
+		t.Fatalf("expected a quorum error without tolerance, got %v", err)
+	}
+	if len(quorumErr.Report.Agreeing) != 1 || len(quorumErr.Report.Disagreements) != 2 {
+		t.Errorf("unexpected quorum report %+v", quorumErr.Report)
+	}
+	if d := quorumErr.Report.Disagreements[0].Differences; len(d) != 1 || d[0].Kind != DiffValue || d[0].Expected != "1" || d[0].Got != "1.05" {
+		t.Errorf("unexpected differences %+v", d)
+	}
+
+	if _, err := cl.Query(context.Background(), QueryData{Query: "up", Mode: QueryModeQuorum, Quorum: 4}); err == nil {
+		t.Error("expected an error for a quorum above the number of backends, got nil")
+	}
+}
+
+func TestClientQuery_QuorumCountsFailures(t *testing.T) {
+	a := vectorServer(t, "1")
+	defer a.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+
+	cl, err := New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = cl.Query(context.Background(), QueryData{Query: "up", Backends: []string{a.URL, failing.URL}, Mode: QueryModeQuorum, Quorum: 2})
+	var quorumErr *QuorumError
+	if !errors.As(err, &quorumErr) || len(quorumErr.Report.Agreeing) != 1 {
+		t.Errorf("expected the failed backend not to count towards the quorum, got %v", err)
+	}
+	if _, err := cl.Query(context.Background(), QueryData{Query: "up", Backends: []string{a.URL, failing.URL}, Mode: QueryModeQuorum, Quorum: 1}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestClientQuery_QuorumNonTransitiveTolerance(t *testing.T) {
+	var backends []Backend
+	for i, v := range []string{"0", "1", "2", "2", "2"} {
+		ts := vectorServer(t, v)
+		defer ts.Close()
+		backends = append(backends, Backend{Name: fmt.Sprint(i), URL: ts.URL})
+	}
+	cl, err := New(WithBackends(backends...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 1 is grouped with 0 but also agrees with 2, the value of the quorum
+	merged, err := cl.Query(context.Background(), QueryData{Query: "up", Mode: QueryModeQuorum, Quorum: 3, Tolerance: Tolerance{Absolute: 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := merged.Quorum
+	if r == nil || len(r.Agreeing) != 4 || r.Agreeing[0] != "2" || len(r.Disagreements) != 1 || r.Disagreements[0].Backend != "0" {
+		t.Fatalf("unexpected quorum report %+v", merged.Quorum)
+	}
+	for _, d := range r.Disagreements {
+		if len(d.Differences) == 0 {
+			t.Errorf("expected differences for disagreeing backend %s", d.Backend)
+		}
+	}
+	if merged.AnsweredBy != "2" || merged.Data.Vector[0].Value.V != 2 {
+		t.Errorf("expected the result of the quorum, got %q and %+v", merged.AnsweredBy, merged.Data)
+	}
+	if len(merged.Warnings) != 1 {
+		t.Errorf("expected a single warning, got %v", merged.Warnings)
+	}
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResultType is the resultType reported by the Prometheus query API
+type ResultType string
+
+const (
+	ResultTypeVector ResultType = "vector"
+	ResultTypeMatrix ResultType = "matrix"
+	ResultTypeScalar ResultType = "scalar"
+	ResultTypeString ResultType = "string"
+)
+
+// Labels is a set of label name/value pairs identifying a series
+type Labels map[string]string
+
+// String renders the label set in PromQL selector syntax with sorted names
+func (l Labels) String() string {
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s=%q", name, l[name])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Point is a single sample: a millisecond timestamp and its value
+type Point struct {
+	T int64
+	V float64
+}
+
+// Time returns the sample timestamp as a time.Time
+func (p Point) Time() time.Time {
+	return time.UnixMilli(p.T)
+}
+
+// MarshalJSON encodes the point as Prometheus does: [<unix seconds>, "<value>"]
+func (p Point) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("[%s,%q]", formatTimestamp(p.T), strconv.FormatFloat(p.V, 'f', -1, 64))), nil
+}
+
+// UnmarshalJSON decodes a [<unix seconds>, "<value>"] pair
+func (p *Point) UnmarshalJSON(b []byte) error {
+	t, raw, err := decodePair(b)
+	if err != nil {
+		return err
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return fmt.Errorf("invalid sample value %q: %w", raw, err)
+	}
+	p.T, p.V = t, v
+	return nil
+}
+
+// StringPoint is the payload of a string result
+type StringPoint struct {
+	T int64
+	V string
+}
+
+// MarshalJSON encodes the point as [<unix seconds>, "<value>"]
+func (p StringPoint) MarshalJSON() ([]byte, error) {
+	v, err := json.Marshal(p.V)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("[%s,%s]", formatTimestamp(p.T), v)), nil
+}
+
+// UnmarshalJSON decodes a [<unix seconds>, "<value>"] pair
+func (p *StringPoint) UnmarshalJSON(b []byte) error {
+	t, v, err := decodePair(b)
+	if err != nil {
+		return err
+	}
+	p.T, p.V = t, v
+	return nil
+}
+
+// Sample is a single element of an instant vector
+type Sample struct {
+	Metric Labels `json:"metric"`
+	Value  Point  `json:"value"`
+}
+
+// Series is a single element of a range matrix
+type Series struct {
+	Metric Labels  `json:"metric"`
+	Values []Point `json:"values"`
+}
+
+// Vector is the result of an instant query selecting series
+type Vector []Sample
+
+// Matrix is the result of a range query
+type Matrix []Series
+
+// ResultData is the decoded data section of a query response. Exactly one of
+// Vector, Matrix, Scalar or String is populated, according to Type
+type ResultData struct {
+	Type   ResultType
+	Vector Vector
+	Matrix Matrix
+	Scalar *Point
+	String *StringPoint
+}
+
+type rawResultData struct {
+	ResultType ResultType      `json:"resultType"`
+	Result     json.RawMessage `json:"result"`
+}
+
+// MarshalJSON encodes the data as a Prometheus {resultType, result} document.
+// Data without a result type, as carried by error responses, encodes as null
+func (d ResultData) MarshalJSON() ([]byte, error) {
+	var result any
+	switch d.Type {
+	case "":
+		return []byte("null"), nil
+	case ResultTypeVector:
+		result = d.Vector
+		if d.Vector == nil {
+			result = Vector{}
+		}
+	case ResultTypeMatrix:
+		result = d.Matrix
+		if d.Matrix == nil {
+			result = Matrix{}
+		}
+	case ResultTypeScalar:
+		result = d.Scalar
+	case ResultTypeString:
+		result = d.String
+	default:
+		return nil, fmt.Errorf("unknown result type %q", d.Type)
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(rawResultData{ResultType: d.Type, Result: raw})
+}
+
+// UnmarshalJSON decodes a {resultType, result} document into the matching type
+func (d *ResultData) UnmarshalJSON(b []byte) error {
+	var raw rawResultData
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*d = ResultData{Type: raw.ResultType}
+	if len(raw.Result) == 0 || string(raw.Result) == "null" {
+		return nil
+	}
+	switch raw.ResultType {
+	case ResultTypeVector:
+		return json.Unmarshal(raw.Result, &d.Vector)
+	case ResultTypeMatrix:
+		return json.Unmarshal(raw.Result, &d.Matrix)
+	case ResultTypeScalar:
+		d.Scalar = &Point{}
+		return json.Unmarshal(raw.Result, d.Scalar)
+	case ResultTypeString:
+		d.String = &StringPoint{}
+		return json.Unmarshal(raw.Result, d.String)
+	default:
+		return fmt.Errorf("unknown result type %q", raw.ResultType)
+	}
+}
+
+// formatTimestamp renders a millisecond timestamp as fractional unix seconds
+func formatTimestamp(ms int64) string {
+	return strconv.FormatFloat(float64(ms)/1e3, 'f', -1, 64)
+}
+
+// decodePair splits a [<unix seconds>, "<string>"] pair into a millisecond
+// timestamp and the raw string
+func decodePair(b []byte) (int64, string, error) {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return 0, "", err
+	}
+	if len(pair) != 2 {
+		return 0, "", fmt.Errorf("expected [timestamp, value] pair, got %d elements", len(pair))
+	}
+	secs, err := strconv.ParseFloat(string(pair[0]), 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid sample timestamp %s: %w", pair[0], err)
+	}
+	var v string
+	if err := json.Unmarshal(pair[1], &v); err != nil {
+		return 0, "", fmt.Errorf("invalid sample value %s: %w", pair[1], err)
+	}
+	return int64(math.Round(secs * 1e3)), v, nil
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPrometheusResponse_DecodeResultTypes(t *testing.T) {
+	cases := []struct {
+		name  string
+		body  string
+		check func(t *testing.T, d ResultData)
+	}{
+		{
+			name: "vector",
+			body: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"up","job":"prometheus"},"value":[1435781451.781,"1"]}]}}`,
+			check: func(t *testing.T, d ResultData) {
+				want := Vector{{Metric: Labels{"__name__": "up", "job": "prometheus"}, Value: Point{T: 1435781451781, V: 1}}}
+				if !reflect.DeepEqual(d.Vector, want) {
+					t.Errorf("unexpected vector: %+v", d.Vector)
+				}
+			},
+		},
+		{
+			name: "matrix",
+			body: `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"job":"node"},"values":[[1435781430.781,"1"],[1435781445.781,"NaN"],[1435781460.781,"+Inf"]]}]}}`,
+			check: func(t *testing.T, d ResultData) {
+				if len(d.Matrix) != 1 || len(d.Matrix[0].Values) != 3 {
+					t.Fatalf("unexpected matrix: %+v", d.Matrix)
+				}
+				values := d.Matrix[0].Values
+				if values[0].T != 1435781430781 || values[0].V != 1 {
+					t.Errorf("unexpected first point: %+v", values[0])
+				}
+				if !math.IsNaN(values[1].V) || !math.IsInf(values[2].V, 1) {
+					t.Errorf("expected NaN and +Inf, got %v and %v", values[1].V, values[2].V)
+				}
+			},
+		},
+		{
+			name: "scalar",
+			body: `{"status":"success","data":{"resultType":"scalar","result":[1435781451.781,"2.5"]}}`,
+			check: func(t *testing.T, d ResultData) {
+				if d.Scalar == nil || *d.Scalar != (Point{T: 1435781451781, V: 2.5}) {
+					t.Errorf("unexpected scalar: %+v", d.Scalar)
+				}
+			},
+		},
+		{
+			name: "string",
+			body: `{"status":"success","data":{"resultType":"string","result":[1435781451.781,"hello"]}}`,
+			check: func(t *testing.T, d ResultData) {
+				if d.String == nil || *d.String != (StringPoint{T: 1435781451781, V: "hello"}) {
+					t.Errorf("unexpected string: %+v", d.String)
+				}
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var resp PrometheusResponse
+			if err := json.Unmarshal([]byte(c.body), &resp); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			if string(resp.Data.Type) != c.name {
+				t.Errorf("expected result type %q, got %q", c.name, resp.Data.Type)
+			}
+			c.check(t, resp.Data)
+		})
+	}
+}
+
+func TestPrometheusResponse_DecodeError(t *testing.T) {
+	body := `{"status":"error","errorType":"bad_data","error":"parse error","warnings":["partial"]}`
+	var resp PrometheusResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if resp.Status != "error" || resp.ErrorType != "bad_data" || resp.Error != "parse error" {
+		t.Errorf("unexpected error fields: %+v", resp)
+	}
+	if !reflect.DeepEqual(resp.Warnings, []string{"partial"}) {
+		t.Errorf("unexpected warnings: %v", resp.Warnings)
+	}
+}
+
+func TestResultData_RoundTrip(t *testing.T) {
+	body := `{"resultType":"matrix","result":[{"metric":{"job":"node"},"values":[[1435781430.781,"1"],[1435781445,"-Inf"]]}]}`
+	var d ResultData
+	if err := json.Unmarshal([]byte(body), &d); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	out, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if string(out) != body {
+		t.Errorf("round trip mismatch:\n got %s\nwant %s", out, body)
+	}
+}
+
+func TestLabels_String(t *testing.T) {
+	l := Labels{"job": "node", "__name__": "up", "instance": `a"b`}
+	want := `{__name__="up", instance="a\"b", job="node"}`
+	if l.String() != want {
+		t.Errorf("got %s, want %s", l.String(), want)
+	}
+}
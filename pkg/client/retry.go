@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// Prometheus errorType values that decide whether a request is retried
+const (
+	ErrorTypeTimeout     = "timeout"
+	ErrorTypeUnavailable = "unavailable"
+	ErrorTypeBadData     = "bad_data"
+)
+
+// DefaultRetryPolicy retries a failed backend request twice, waiting up to
+// 100ms and then up to 200ms
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Jitter:         0.2,
+}
+
+// RetryPolicy controls how backend requests that failed with a retryable
+// error are retried. Retries stop early when the query's deadline would pass
+// before the next attempt
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one, one or
+	// less disables retries
+	MaxAttempts int `yaml:"max_attempts"`
+
+	// InitialBackoff is the wait before the first retry, doubling for every
+	// further retry up to MaxBackoff
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+
+	// Jitter is the fraction of each backoff that is randomized, between 0
+	// and 1, so that clients do not retry in lockstep
+	Jitter float64 `yaml:"jitter"`
+}
+
+func (p RetryPolicy) validate() error {
+	if p.MaxAttempts < 0 || p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		return errors.New("retry attempts and backoffs must not be negative")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return errors.New("retry jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// backoff returns the wait before the retry following attempt, counted from 1
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff == 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	jitter := time.Duration(float64(delay) * p.Jitter * rand.Float64())
+	return delay - jitter
+}
+
+// retryable reports whether a failed backend request may succeed when sent
+// again: network failures, attempts that timed out, 5xx and 429 responses and
+// Prometheus timeout and unavailable errors. Bad queries are never retried
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Type {
+		case ErrorTypeTimeout, ErrorTypeUnavailable:
+			return true
+		case ErrorTypeBadData:
+			return false
+		}
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// wait sleeps for the backoff before the retry following attempt. It returns
+// false without waiting when ctx would expire first, or when it does
+func (p RetryPolicy) wait(ctx context.Context, attempt int) bool {
+	delay := p.backoff(attempt)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+		return false
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&APIError{StatusCode: http.StatusBadGateway}, true},
+		{&APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{&APIError{StatusCode: http.StatusServiceUnavailable, Type: ErrorTypeUnavailable}, true},
+		{&APIError{StatusCode: http.StatusUnprocessableEntity, Type: ErrorTypeTimeout}, true},
+		{&APIError{StatusCode: http.StatusBadRequest, Type: ErrorTypeBadData}, false},
+		{&APIError{StatusCode: http.StatusInternalServerError, Type: ErrorTypeBadData}, false},
+		{&APIError{StatusCode: http.StatusUnprocessableEntity, Type: "execution"}, false},
+		{&APIError{StatusCode: http.StatusNotFound}, false},
+		{fmt.Errorf("get: %w", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}), true},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{io.ErrUnexpectedEOF, true},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{fmt.Errorf("invalid character 'x' looking for beginning of value"), false},
+	}
+	for _, tc := range tests {
+		if got := retryable(tc.err); got != tc.want {
+			t.Errorf("retryable(%v) = %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Jitter: 0.5}
+	for attempt, limit := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 10: time.Second} {
+		for range 20 {
+			if got := p.backoff(attempt); got > limit || got < limit/2 {
+				t.Errorf("backoff after attempt %d = %s, want between %s and %s", attempt, got, limit/2, limit)
+			}
+		}
+	}
+}
+
+func TestClientQuery_Retries(t *testing.T) {
+	var calls atomic.Int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		mockPrometheusHandler(t)(w, r)
+	}))
+	defer flaky.Close()
+	badQuery := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		if _, err := w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer badQuery.Close()
+
+	c, err := New(WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	merged, err := c.Query(context.Background(), QueryData{Query: "up", Backends: []string{flaky.URL, badQuery.URL}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := merged.Backends[0]; s.Status != BackendStatusSuccess || s.Attempts != 3 || s.Error != "" {
+		t.Errorf("expected success on the third attempt, got %+v", s)
+	}
+	if s := merged.Backends[1]; s.Status != BackendStatusError || s.Attempts != 1 || s.HTTPStatus != http.StatusBadRequest {
+		t.Errorf("expected bad_data not to be retried, got %+v", s)
+	}
+
+	calls.Store(0)
+	c, err = New(WithRetryPolicy(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	started := time.Now()
+	_, err = c.Query(context.Background(), QueryData{Query: "up", Backends: []string{flaky.URL, badQuery.URL}, Timeout: time.Second})
+	if err == nil {
+		t.Fatal("expected every backend to fail, got nil")
+	}
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Errorf("expected retries to stop before a backoff past the deadline, took %s", elapsed)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected a single attempt, got %d", got)
+	}
+}
+
+func TestWithRetryPolicy_Invalid(t *testing.T) {
+	if _, err := New(WithRetryPolicy(RetryPolicy{MaxAttempts: 2, Jitter: 2})); err == nil {
+		t.Error("expected error for jitter above 1, got nil")
+	}
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeWindow is the span of time a backend holds data for, relative to the
+// moment it is queried. The zero TimeWindow holds all of time
+type TimeWindow struct {
+	// MaxAge is how far back the backend's data goes, such as the retention
+	// of a short-term Prometheus. Zero means no limit
+	MaxAge time.Duration `yaml:"max_age"`
+
+	// MinAge is how old data must be for the backend to hold it, such as the
+	// delay before blocks reach long-term storage. Zero means up to now
+	MinAge time.Duration `yaml:"min_age"`
+}
+
+// IsZero reports whether the window holds all of time
+func (w TimeWindow) IsZero() bool {
+	return w == TimeWindow{}
+}
+
+func (w TimeWindow) validate() error {
+	if w.MaxAge < 0 || w.MinAge < 0 {
+		return errors.New("time window ages must not be negative")
+	}
+	if w.MaxAge > 0 && w.MaxAge <= w.MinAge {
+		return errors.New("time window max_age must be greater than min_age")
+	}
+	return nil
+}
+
+// covers reports whether the window holds t at now
+func (w TimeWindow) covers(now, t time.Time) bool {
+	if w.MaxAge > 0 && t.Before(now.Add(-w.MaxAge)) {
+		return false
+	}
+	return w.MinAge == 0 || !t.After(now.Add(-w.MinAge))
+}
+
+// span returns the part of the range from start to end the window holds at
+// now, keeping its bounds on the range's step grid so the samples line up
+// with those of other backends, and false when it holds none of it
+func (w TimeWindow) span(now, start, end time.Time, step time.Duration) (time.Time, time.Time, bool) {
+	from, to := start, end
+	if w.MaxAge > 0 {
+		if oldest := now.Add(-w.MaxAge); oldest.After(from) {
+			from = start.Add(stepsUntil(oldest.Sub(start), step, true))
+		}
+	}
+	if w.MinAge > 0 {
+		if newest := now.Add(-w.MinAge); newest.Before(to) {
+			if newest.Before(start) {
+				return time.Time{}, time.Time{}, false
+			}
+			to = start.Add(stepsUntil(newest.Sub(start), step, false))
+		}
+	}
+	return from, to, !from.After(to)
+}
+
+// stepsUntil rounds d to a multiple of step, up or down
+func stepsUntil(d, step time.Duration, up bool) time.Duration {
+	if step <= 0 {
+		return d
+	}
+	n := d / step
+	if up && d%step != 0 {
+		n++
+	}
+	return n * step
+}
+
+// RouteDecision explains whether a query is sent to a backend
+type RouteDecision struct {
+	Backend string `json:"backend"`
+	Queried bool   `json:"queried"`
+
+	// Start and End are the part of a range query the backend is asked for
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+
+	Reason string `json:"reason"`
+}
+
+// route keeps the backends that may hold data for the query, explaining the
+// decision for each of them. A backend is left out when its time window does
+// not hold the query's time, or when its external labels, the Labels of the
+// backend, rule out every series selector of the query. Range queries go to
+// the backends holding part of the range when partial is set, and to the ones
+// holding all of it otherwise. Queries that cannot be parsed go to every
+// backend whose time window holds them
+func route(backends []Backend, data QueryData, partial bool) ([]Backend, []RouteDecision) {
+	selectors, parseErr := parseSelectors(data.Query)
+	var routed []Backend
+	decisions := make([]RouteDecision, 0, len(backends))
+	for _, b := range backends {
+		d := RouteDecision{Backend: b.String()}
+		var reasons []string
+		switch {
+		case b.TimeWindow.IsZero():
+		case !data.IsRange():
+			if !b.TimeWindow.covers(data.now, data.Time) {
+				d.Reason = "time window does not hold the evaluation time"
+			}
+		default:
+			from, to, ok := b.TimeWindow.span(data.now, data.Start, data.End, data.Step)
+			whole := from.Equal(data.Start) && to.Equal(data.End)
+			switch {
+			case !ok:
+				d.Reason = "time window holds none of the range"
+			case !whole && !partial:
+				d.Reason = "time window holds only part of the range"
+			case !whole:
+				d.Start, d.End = &from, &to
+				reasons = append(reasons, "time window holds part of the range")
+			}
+		}
+		if d.Reason != "" {
+			decisions = append(decisions, d)
+			continue
+		}
+
+		switch excluded := excludingMatchers(b.Labels, selectors); {
+		case len(b.Labels) == 0:
+			reasons = append(reasons, "no external labels")
+		case parseErr != nil:
+			reasons = append(reasons, fmt.Sprintf("query not understood, sent to every backend: %v", parseErr))
+		case len(excluded) > 0:
+			var matchers []string
+			for _, m := range excluded {
+				matchers = append(matchers, m.String())
+			}
+			d.Reason = fmt.Sprintf("external labels %s do not match %s", b.Labels, strings.Join(matchers, ", "))
+			decisions = append(decisions, d)
+			continue
+		default:
+			reasons = append(reasons, fmt.Sprintf("external labels %s may match the query", b.Labels))
+		}
+		d.Queried, d.Reason = true, strings.Join(reasons, ", ")
+		decisions = append(decisions, d)
+		routed = append(routed, b)
+	}
+	return routed, decisions
+}
+
+// Explain reports which backends Query would send data.Query to, and why
+func (c *Client) Explain(data QueryData) ([]RouteDecision, error) {
+	data = c.withDefaults(data)
+	mode, err := ParseQueryMode(string(data.Mode))
+	if err != nil {
+		return nil, err
+	}
+	_, decisions := route(c.queryBackends(data), pinTimes(data), mode == QueryModeMerge)
+	return decisions, nil
+}
+
+// excludingMatchers returns, for every selector, the first matcher that no
+// series of a backend with the given external labels can satisfy. It returns
+// nil when a selector may match, or when there are no selectors at all
+func excludingMatchers(labels Labels, selectors [][]labelMatcher) []labelMatcher {
+	var excluded []labelMatcher
+	for _, selector := range selectors {
+		found := false
+		for _, m := range selector {
+			if v, ok := labels[m.name]; ok && !m.matches(v) {
+				excluded = append(excluded, m)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return excluded
+}
+
+// noRouteError explains why no backend was sent a query
+func noRouteError(decisions []RouteDecision) error {
+	var reasons []string
+	for _, d := range decisions {
+		reasons = append(reasons, d.Backend+": "+d.Reason)
+	}
+	return fmt.Errorf("no backend holds data for the query: %s", strings.Join(reasons, "; "))
+}
+
+// timeRouted reports whether any of the backends is limited to a time window
+func timeRouted(backends []Backend) bool {
+	for _, b := range backends {
+		if !b.TimeWindow.IsZero() {
+			return true
+		}
+	}
+	return false
+}
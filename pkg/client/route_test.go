@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimeWindow_Span(t *testing.T) {
+	now := time.Unix(10000, 0)
+	start, end := time.Unix(0, 0), now
+	cases := []struct {
+		name     string
+		window   TimeWindow
+		from, to int64
+		ok       bool
+	}{
+		{"all of time", TimeWindow{}, 0, 10000, true},
+		{"recent aligned up", TimeWindow{MaxAge: 3500 * time.Second}, 7000, 10000, true},
+		{"older aligned down", TimeWindow{MinAge: 3500 * time.Second}, 0, 6000, true},
+		{"middle", TimeWindow{MaxAge: 5000 * time.Second, MinAge: 2000 * time.Second}, 5000, 8000, true},
+		{"before the range", TimeWindow{MinAge: 20000 * time.Second}, 0, 0, false},
+		{"between two steps", TimeWindow{MaxAge: 5500 * time.Second, MinAge: 5200 * time.Second}, 0, 0, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			from, to, ok := c.window.span(now, start, end, 1000*time.Second)
+			if ok != c.ok || (ok && (from.Unix() != c.from || to.Unix() != c.to)) {
+				t.Errorf("got %d-%d, %t, expected %d-%d, %t", from.Unix(), to.Unix(), ok, c.from, c.to, c.ok)
+			}
+		})
+	}
+}
+
+// backendNames joins the names of the backends with commas
+func backendNames(backends []Backend) string {
+	var out []string
+	for _, b := range backends {
+		out = append(out, b.Name)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestRoute_TimeWindows(t *testing.T) {
+	now := time.Unix(100000, 0)
+	backends := []Backend{
+		{Name: "recent", TimeWindow: TimeWindow{MaxAge: 6 * time.Hour}},
+		{Name: "history", TimeWindow: TimeWindow{MinAge: 2 * time.Hour}},
+		{Name: "everything"},
+	}
+	cases := []struct {
+		name    string
+		data    QueryData
+		partial bool
+		want    string
+	}{
+		{"recent instant", QueryData{Query: "up", Time: now.Add(-time.Hour)}, false, "recent,everything"},
+		{"overlap instant", QueryData{Time: now.Add(-3 * time.Hour)}, false, "recent,history,everything"},
+		{"old instant", QueryData{Time: now.Add(-24 * time.Hour)}, false, "history,everything"},
+		{"split range", QueryData{Start: now.Add(-24 * time.Hour), End: now, Step: time.Minute}, true, "recent,history,everything"},
+		{"whole range", QueryData{Start: now.Add(-24 * time.Hour), End: now, Step: time.Minute}, false, "everything"},
+		{"recent range", QueryData{Start: now.Add(-time.Hour), End: now, Step: time.Minute}, false, "recent,everything"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.data.now = now
+			routed, _ := route(backends, c.data, c.partial)
+			if got := backendNames(routed); got != c.want {
+				t.Errorf("got %s, expected %s", got, c.want)
+			}
+		})
+	}
+}
+
+// rangeServer answers range queries with one series holding value at every
+// step of the requested range, and records the ranges it was asked for
+type rangeServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	ranges [][2]float64
+}
+
+func newRangeServer(t *testing.T, value string) *rangeServer {
+	s := &rangeServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.ParseFloat(r.FormValue("start"), 64)
+		end, _ := strconv.ParseFloat(r.FormValue("end"), 64)
+		step, _ := strconv.ParseFloat(r.FormValue("step"), 64)
+		s.mu.Lock()
+		s.ranges = append(s.ranges, [2]float64{start, end})
+		s.mu.Unlock()
+		var values []string
+		for ts := start; ts <= end; ts += step {
+			values = append(values, fmt.Sprintf("[%s,%q]", strconv.FormatFloat(ts, 'f', -1, 64), value))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		body := `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"job":"node"},"values":[` + strings.Join(values, ",") + `]}]}}`
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	return s
+}
+
+func TestClientQuery_SplitsRangeByTimeWindow(t *testing.T) {
+	recent := newRangeServer(t, "1")
+	defer recent.Close()
+	history := newRangeServer(t, "2")
+	defer history.Close()
+
+	c, err := New(WithBackends(
+		Backend{Name: "recent", URL: recent.URL, TimeWindow: TimeWindow{MaxAge: 6 * time.Hour}},
+		Backend{Name: "history", URL: history.URL, TimeWindow: TimeWindow{MinAge: 2 * time.Hour}},
+	))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Now().Add(-24 * time.Hour).Truncate(time.Hour)
+	end := start.Add(24 * time.Hour)
+	merged, err := c.Query(context.Background(), QueryData{Query: "up", Start: start, End: end, Step: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(recent.ranges) != 1 || recent.ranges[0][1] != float64(end.Unix()) || recent.ranges[0][0] < float64(end.Add(-6*time.Hour).Unix()) {
+		t.Errorf("expected the recent backend to be asked for the last 6h, got %v", recent.ranges)
+	}
+	if len(history.ranges) != 1 || history.ranges[0][0] != float64(start.Unix()) || history.ranges[0][1] > float64(end.Add(-time.Hour).Unix()) {
+		t.Errorf("expected the history backend to be asked for data older than 2h, got %v", history.ranges)
+	}
+	if len(merged.Data.Matrix) != 1 {
+		t.Fatalf("expected the series to be stitched into one, got %+v", merged.Data.Matrix)
+	}
+	points := merged.Data.Matrix[0].Values
+	if len(points) != 25 {
+		t.Errorf("expected 25 hourly samples without duplicates, got %d", len(points))
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i].T-points[i-1].T != time.Hour.Milliseconds() {
+			t.Errorf("expected hourly samples, got %v", points)
+			break
+		}
+	}
+	if first, last := points[0].V, points[len(points)-1].V; first != 2 || last != 1 {
+		t.Errorf("expected old samples from history and recent ones from the recent backend, got %g and %g", first, last)
+	}
+
+	if _, err := c.Query(context.Background(), QueryData{Query: "up", Time: time.Now().Add(time.Hour), Backends: []string{history.URL}}); err == nil {
+		t.Error("expected an error when no backend holds the evaluation time, got nil")
+	}
+}
+
+func TestRoute_ExternalLabels(t *testing.T) {
+	backends := []Backend{
+		{Name: "eu", Labels: Labels{"cluster": "eu-1"}},
+		{Name: "us", Labels: Labels{"cluster": "us-1", "env": "prod"}},
+		{Name: "plain"},
+	}
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{`up{cluster="eu-1"}`, "eu,plain"},
+		{`up{cluster=~"us-.*"}`, "us,plain"},
+		{`up{cluster="eu-1"} or up{env="prod"}`, "eu,us,plain"},
+		{`up{cluster="eu-1"} / on() group_left up`, "eu,us,plain"},
+		{`up{cluster="ap-1"}`, "plain"},
+		{`up{job="node"}`, "eu,us,plain"},
+		{`vector(1)`, "eu,us,plain"},
+		{`up{cluster="eu-1"`, "eu,us,plain"},
+	}
+	for _, c := range cases {
+		routed, _ := route(backends, QueryData{Query: c.query}, true)
+		if got := backendNames(routed); got != c.want {
+			t.Errorf("%s: got %s, expected %s", c.query, got, c.want)
+		}
+	}
+}
+
+func TestClient_Explain(t *testing.T) {
+	c, err := New(WithBackends(
+		Backend{Name: "eu", URL: "http://eu:9090", Labels: Labels{"cluster": "eu-1"}},
+		Backend{Name: "us", URL: "http://us:9090", Labels: Labels{"cluster": "us-1"}},
+		Backend{Name: "history", URL: "http://history:9090", TimeWindow: TimeWindow{MinAge: 2 * time.Hour}},
+	))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Now()
+	decisions, err := c.Explain(QueryData{Query: `up{cluster="eu-1"}`, Start: now.Add(-6 * time.Hour), End: now, Step: time.Minute})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []RouteDecision{
+		{Backend: "eu", Queried: true, Reason: `external labels {cluster="eu-1"} may match the query`},
+		{Backend: "us", Reason: `external labels {cluster="us-1"} do not match cluster="eu-1"`},
+		{Backend: "history", Queried: true, Reason: "time window holds part of the range, no external labels"},
+	}
+	if len(decisions) != len(want) {
+		t.Fatalf("got %+v, expected %+v", decisions, want)
+	}
+	for i, d := range decisions {
+		if d.Backend != want[i].Backend || d.Queried != want[i].Queried || d.Reason != want[i].Reason {
+			t.Errorf("got %+v, expected %+v", d, want[i])
+		}
+	}
+	if d := decisions[2]; d.Start == nil || d.End == nil || d.End.After(now.Add(-2*time.Hour)) {
+		t.Errorf("expected the part of the range sent to history, got %+v", d)
+	}
+
+	decisions, err = c.Explain(QueryData{Query: `up{cluster="eu-1"}`, Start: now.Add(-6 * time.Hour), End: now, Step: time.Minute, Mode: QueryModeFailover})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := decisions[2]; d.Queried || d.Reason != "time window holds only part of the range" {
+		t.Errorf("expected failover to skip a backend holding part of the range, got %+v", d)
+	}
+
+	if _, err := c.Query(context.Background(), QueryData{Query: `up{cluster="ap-1"}`, Backends: []string{"http://eu:9090", "http://us:9090"}}); err == nil || !strings.Contains(err.Error(), "no backend holds data for the query") {
+		t.Errorf("expected a routing error, got %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package client
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// labelMatcher is one label matcher of a PromQL series selector
+type labelMatcher struct {
+	name  string
+	op    string
+	value string
+}
+
+func (m labelMatcher) String() string {
+	return m.name + m.op + strconv.Quote(m.value)
+}
+
+// matches reports whether a series with the label set to v may be selected
+// by the matcher. Invalid regular expressions match anything, the backend
+// will report them
+func (m labelMatcher) matches(v string) bool {
+	switch m.op {
+	case "=":
+		return v == m.value
+	case "!=":
+		return v != m.value
+	}
+	re, err := regexp.Compile("^(?:" + m.value + ")$")
+	if err != nil {
+		return true
+	}
+	return re.MatchString(v) == (m.op == "=~")
+}
+
+// selectorKeywords are the identifiers of PromQL that are not metric names
+var selectorKeywords = map[string]bool{
+	"and": true, "or": true, "unless": true, "atan2": true, "bool": true,
+	"offset": true, "inf": true, "nan": true,
+	"by": true, "without": true, "on": true, "ignoring": true,
+	"group_left": true, "group_right": true,
+}
+
+// groupingKeywords are followed by a list of label names in parentheses
+var groupingKeywords = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true,
+	"group_left": true, "group_right": true,
+}
+
+// parseSelectors returns the label matchers of every series selector of a
+// PromQL query. A selector made only of a metric name has no matchers. It
+// is not a full parser: it only tells selectors from the rest of the query
+// well enough to route it, and fails on what it does not understand
+func parseSelectors(query string) ([][]labelMatcher, error) {
+	s := &selectorScanner{query: query}
+	var selectors [][]labelMatcher
+	for {
+		s.skipSpace()
+		if s.done() {
+			return selectors, nil
+		}
+		c := s.query[s.pos]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			if _, err := s.string(); err != nil {
+				return nil, err
+			}
+		case c == '{':
+			matchers, err := s.matchers()
+			if err != nil {
+				return nil, err
+			}
+			selectors = append(selectors, matchers)
+		case c == '[':
+			end := strings.IndexByte(s.query[s.pos:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ at position %d", s.pos)
+			}
+			s.pos += end + 1
+		case isIdentStart(c):
+			name := s.ident()
+			keyword := strings.ToLower(name)
+			s.skipSpace()
+			next := byte(0)
+			if !s.done() {
+				next = s.query[s.pos]
+			}
+			switch {
+			case groupingKeywords[keyword] && next == '(':
+				end := strings.IndexByte(s.query[s.pos:], ')')
+				if end < 0 {
+					return nil, fmt.Errorf("unclosed ( at position %d", s.pos)
+				}
+				s.pos += end + 1
+			case selectorKeywords[keyword] || next == '(' || s.aggregationModifier():
+				// a keyword, or a function or aggregation call
+			case next == '{':
+				matchers, err := s.matchers()
+				if err != nil {
+					return nil, err
+				}
+				selectors = append(selectors, matchers)
+			default:
+				selectors = append(selectors, nil)
+			}
+		case c >= '0' && c <= '9' || c == '.':
+			s.number()
+		default:
+			s.pos++
+		}
+	}
+}
+
+// selectorScanner walks a PromQL query
+type selectorScanner struct {
+	query string
+	pos   int
+}
+
+func (s *selectorScanner) done() bool {
+	return s.pos >= len(s.query)
+}
+
+// skipSpace skips white space and comments
+func (s *selectorScanner) skipSpace() {
+	for !s.done() {
+		switch c := s.query[s.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			s.pos++
+		case c == '#':
+			for !s.done() && s.query[s.pos] != '\n' {
+				s.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// aggregationModifier reports whether by or without comes next, as in
+// sum by (job) (x), without moving past it
+func (s *selectorScanner) aggregationModifier() bool {
+	if s.done() || !isIdentStart(s.query[s.pos]) {
+		return false
+	}
+	pos := s.pos
+	next := strings.ToLower(s.ident())
+	s.pos = pos
+	return next == "by" || next == "without"
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == ':' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func (s *selectorScanner) ident() string {
+	start := s.pos
+	for !s.done() {
+		if c := s.query[s.pos]; !isIdentStart(c) && (c < '0' || c > '9') {
+			break
+		}
+		s.pos++
+	}
+	return s.query[start:s.pos]
+}
+
+// number skips a number or a duration, including exponents such as 1e-3
+func (s *selectorScanner) number() {
+	for !s.done() {
+		c := s.query[s.pos]
+		switch {
+		case c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '.' || c == '_':
+			s.pos++
+		case (c == '+' || c == '-') && (s.query[s.pos-1] == 'e' || s.query[s.pos-1] == 'E'):
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// string reads a quoted string literal
+func (s *selectorScanner) string() (string, error) {
+	quote := s.query[s.pos]
+	start := s.pos
+	for s.pos++; !s.done(); s.pos++ {
+		switch s.query[s.pos] {
+		case '\\':
+			if quote != '`' {
+				s.pos++
+			}
+		case quote:
+			s.pos++
+			lit := s.query[start:s.pos]
+			if quote == '\'' {
+				// Go has no single quoted strings, so requote the contents
+				lit = `"` + strings.ReplaceAll(strings.ReplaceAll(lit[1:len(lit)-1], `\'`, `'`), `"`, `\"`) + `"`
+			}
+			v, err := strconv.Unquote(lit)
+			if err != nil {
+				return "", fmt.Errorf("invalid string %s at position %d", s.query[start:s.pos], start)
+			}
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unclosed string at position %d", start)
+}
+
+// matchers reads the label matchers between braces
+func (s *selectorScanner) matchers() ([]labelMatcher, error) {
+	s.pos++
+	var matchers []labelMatcher
+	for {
+		s.skipSpace()
+		if s.done() {
+			return nil, fmt.Errorf("unclosed { in %q", s.query)
+		}
+		if s.query[s.pos] == '}' {
+			s.pos++
+			return matchers, nil
+		}
+
+		var name string
+		switch c := s.query[s.pos]; {
+		case isIdentStart(c):
+			name = s.ident()
+		case c == '"' || c == '\'' || c == '`':
+			var err error
+			if name, err = s.string(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in selector at position %d", c, s.pos)
+		}
+		s.skipSpace()
+
+		op := ""
+		for _, candidate := range []string{"=~", "!~", "!=", "="} {
+			if strings.HasPrefix(s.query[s.pos:], candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			// a quoted metric name on its own, as in {"metric.name"}
+			matchers = append(matchers, labelMatcher{name: "__name__", op: "=", value: name})
+		} else {
+			s.pos += len(op)
+			s.skipSpace()
+			if s.done() || !strings.ContainsRune("\"'`", rune(s.query[s.pos])) {
+				return nil, fmt.Errorf("expected a string after %s%s at position %d", name, op, s.pos)
+			}
+			value, err := s.string()
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, labelMatcher{name: name, op: op, value: value})
+		}
+
+		s.skipSpace()
+		if !s.done() && s.query[s.pos] == ',' {
+			s.pos++
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSelectors(t *testing.T) {
+	cases := []struct {
+		query string
+		want  [][]labelMatcher
+	}{
+		{`vector(1)`, nil},
+		{`up`, [][]labelMatcher{nil}},
+		{`up{cluster="eu-1", job=~"node|api",}`, [][]labelMatcher{{{"cluster", "=", "eu-1"}, {"job", "=~", "node|api"}}}},
+		{
+			`sum by (cluster) (rate(http_requests_total{cluster!='us-1'}[5m] offset 1h)) / on(cluster) group_left() max(x{env="prod"}) > bool 1e-3`,
+			[][]labelMatcher{{{"cluster", "!=", "us-1"}}, {{"env", "=", "prod"}}},
+		},
+		{`label_replace({__name__="up"}, "dst", "{x}", "src", "(.*)") # {cluster="eu"}`, [][]labelMatcher{{{"__name__", "=", "up"}}}},
+		{`{"metric.name", "cluster"!~` + "`eu-.*`" + `}`, [][]labelMatcher{{{"__name__", "=", "metric.name"}, {"cluster", "!~", "eu-.*"}}}},
+		{`max_over_time(up{job="a"}[1h:5m]) and Inf`, [][]labelMatcher{{{"job", "=", "a"}}}},
+		{`topk without (instance) (3, up)`, [][]labelMatcher{nil}},
+	}
+	for _, c := range cases {
+		got, err := parseSelectors(c.query)
+		if err != nil {
+			t.Errorf("parseSelectors(%q) failed: %v", c.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("parseSelectors(%q) = %v, expected %v", c.query, got, c.want)
+		}
+	}
+	for _, query := range []string{`up{cluster="eu`, `up{cluster=eu}`, `up{cluster="eu"`, `rate(up[5m)`} {
+		if _, err := parseSelectors(query); err == nil {
+			t.Errorf("expected an error for %q, got nil", query)
+		}
+	}
+}
+
+func TestLabelMatcher_Matches(t *testing.T) {
+	cases := []struct {
+		matcher labelMatcher
+		value   string
+		want    bool
+	}{
+		{labelMatcher{"c", "=", "eu"}, "eu", true},
+		{labelMatcher{"c", "=", "eu"}, "us", false},
+		{labelMatcher{"c", "!=", "eu"}, "us", true},
+		{labelMatcher{"c", "=~", "eu-.*"}, "eu-1", true},
+		{labelMatcher{"c", "=~", "eu"}, "eu-1", false},
+		{labelMatcher{"c", "!~", "eu-.*"}, "eu-1", false},
+		{labelMatcher{"c", "=~", "("}, "eu-1", true},
+	}
+	for _, c := range cases {
+		if got := c.matcher.matches(c.value); got != c.want {
+			t.Errorf("%s matches %q = %t, expected %t", c.matcher, c.value, got, c.want)
+		}
+	}
+}
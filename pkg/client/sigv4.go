@@ -0,0 +1,201 @@
+package client
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultSigV4Service is the signing name of Amazon Managed Service for Prometheus
+const DefaultSigV4Service = "aps"
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+)
+
+// SigV4 signs the requests to a backend with AWS Signature Version 4. Without
+// static keys the credentials are read from the standard AWS environment
+// variables on every request
+type SigV4 struct {
+	Region string `yaml:"region"`
+
+	// Service is the signing name, defaulting to DefaultSigV4Service
+	Service string `yaml:"service"`
+
+	AccessKey    string `yaml:"access_key"`
+	SecretKey    string `yaml:"secret_key"`
+	SessionToken string `yaml:"session_token"`
+}
+
+type sigV4Credentials struct {
+	accessKey, secretKey, sessionToken string
+}
+
+func (s *SigV4) validate() error {
+	if s.Region == "" {
+		return errors.New("SigV4 needs a region")
+	}
+	if (s.AccessKey == "") != (s.SecretKey == "") {
+		return errors.New("SigV4 access_key and secret_key must be set together")
+	}
+	return nil
+}
+
+func (s *SigV4) service() string {
+	if s.Service == "" {
+		return DefaultSigV4Service
+	}
+	return s.Service
+}
+
+func (s *SigV4) credentials() (sigV4Credentials, error) {
+	if s.AccessKey != "" {
+		return sigV4Credentials{s.AccessKey, s.SecretKey, s.SessionToken}, nil
+	}
+	creds := sigV4Credentials{
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.accessKey == "" || creds.secretKey == "" {
+		return creds, errors.New("no SigV4 credentials configured and AWS_ACCESS_KEY_ID or AWS_SECRET_ACCESS_KEY is unset")
+	}
+	return creds, nil
+}
+
+// Authenticate signs the request, reading its body to hash the payload
+func (s *SigV4) Authenticate(req *http.Request) error {
+	creds, err := s.credentials()
+	if err != nil {
+		return err
+	}
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return err
+		}
+		if err := req.Body.Close(); err != nil {
+			return err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	signSigV4(req, body, creds, s.Region, s.service(), time.Now())
+	return nil
+}
+
+// signSigV4 adds the X-Amz-Date, X-Amz-Security-Token and Authorization
+// headers of a SigV4 signature made at now. The host, content type and
+// X-Amz headers are signed
+func signSigV4(req *http.Request, body []byte, creds sigV4Credentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format(sigV4TimeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "x-amz-date" || name == "x-amz-security-token" {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4Escape(path, false),
+		sigV4Query(req),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	date := amzDate[:8]
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := sigV4Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, creds.accessKey, scope, signedHeaders, signature))
+}
+
+// sigV4Query returns the query string with keys and values escaped and
+// sorted as SigV4 expects
+func sigV4Query(req *http.Request) string {
+	query := req.URL.Query()
+	pairs := make([][2]string, 0, len(query))
+	for key, values := range query {
+		for _, v := range values {
+			pairs = append(pairs, [2]string{sigV4Escape(key, true), sigV4Escape(v, true)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	encoded := make([]string, len(pairs))
+	for i, p := range pairs {
+		encoded[i] = p[0] + "=" + p[1]
+	}
+	return strings.Join(encoded, "&")
+}
+
+// sigV4Escape percent-encodes everything but unreserved characters, and
+// slashes unless escapeSlash is set
+func sigV4Escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
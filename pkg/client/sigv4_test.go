@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSignSigV4 checks signatures from the AWS documentation: the
+// get-vanilla case of the SigV4 test suite and the IAM ListUsers example
+func TestSignSigV4(t *testing.T) {
+	creds := sigV4Credentials{accessKey: "AKIDEXAMPLE", secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	cases := []struct {
+		url, contentType, service string
+		want                      string
+	}{
+		{
+			url:     "https://example.amazonaws.com/",
+			service: "service",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, " +
+				"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			url:         "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08",
+			contentType: "application/x-www-form-urlencoded; charset=utf-8",
+			service:     "iam",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, " +
+				"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		signSigV4(req, nil, creds, "us-east-1", tc.service, now)
+		if got := req.Header.Get("Authorization"); got != tc.want {
+			t.Errorf("%s: got Authorization\n%s\nwant\n%s", tc.url, got, tc.want)
+		}
+		if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+			t.Errorf("%s: unexpected X-Amz-Date %q", tc.url, got)
+		}
+	}
+}
+
+func TestSigV4Query(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://aps.example/api/v1/query?query=up%7Bjob%3D%22a+b%22%7D&a=2&a=1&time=1", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := sigV4Query(req), "a=1&a=2&query=up%7Bjob%3D%22a%20b%22%7D&time=1"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestClient_SigV4FromEnv(t *testing.T) {
+	type seen struct {
+		auth, token, body string
+	}
+	requests := make(chan seen, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read body: %v", err)
+		}
+		requests <- seen{r.Header.Get("Authorization"), r.Header.Get("X-Amz-Security-Token"), string(body)}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+	c, err := New(WithRequestMethod(RequestMethodPOST), WithBackends(Backend{Name: "amp", URL: ts.URL, SigV4: &SigV4{Region: "eu-west-1"}}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.QueryPrometheus(context.Background(), "amp", "up"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := <-requests
+	if !strings.HasPrefix(got.auth, "AWS4-HMAC-SHA256 Credential=AKIDENV/") || !strings.Contains(got.auth, "/eu-west-1/aps/aws4_request") {
+		t.Errorf("unexpected Authorization %q", got.auth)
+	}
+	if !strings.Contains(got.auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token") || got.token != "session" {
+		t.Errorf("expected the session token to be sent and signed, got %+v", got)
+	}
+	if !strings.Contains(got.body, "query=up") {
+		t.Errorf("expected the signed body to reach the backend, got %q", got.body)
+	}
+
+	if _, err := New(WithBackends(Backend{URL: ts.URL, SigV4: &SigV4{}})); err == nil {
+		t.Error("expected error for SigV4 without region, got nil")
+	}
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TenantHeader carries the tenant ID of requests to multi-tenant Cortex and Mimir
+const TenantHeader = "X-Scope-OrgID"
+
+// TenantLabel is added to every series of a query fanned out over tenants
+const TenantLabel = "__tenant_id__"
+
+// maxTenantLength is the longest tenant ID Cortex accepts
+const maxTenantLength = 150
+
+// ValidateTenant checks that tenant is a valid Cortex tenant ID, or several
+// joined with | for tenant federation
+func ValidateTenant(tenant string) error {
+	for _, id := range strings.Split(tenant, "|") {
+		switch {
+		case id == "":
+			return fmt.Errorf("empty tenant ID in %q", tenant)
+		case id == "." || id == "..":
+			return fmt.Errorf("tenant ID %q is not allowed", id)
+		case len(id) > maxTenantLength:
+			return fmt.Errorf("tenant ID %q is longer than %d characters", id, maxTenantLength)
+		}
+		for _, r := range id {
+			if !isTenantRune(r) {
+				return fmt.Errorf("tenant ID %q contains unsupported character %q", id, r)
+			}
+		}
+	}
+	return nil
+}
+
+// isTenantRune reports whether r is one of the characters Cortex allows in a
+// tenant ID
+func isTenantRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("!-_.*'()", r)
+}
+
+// forTenants repeats every backend once per tenant. Each copy is named after
+// its tenant so that merged output and backend statuses tell them apart
+func forTenants(backends []Backend, tenants []string) []Backend {
+	if len(tenants) == 0 {
+		return backends
+	}
+	out := make([]Backend, 0, len(backends)*len(tenants))
+	for _, b := range backends {
+		for _, tenant := range tenants {
+			tb := b
+			tb.Name, tb.Tenant = tenant+"@"+b.String(), tenant
+			out = append(out, tb)
+		}
+	}
+	return out
+}
+
+// withResultLabel returns d with name set to value on every series. Scalar
+// and string results have no labels and are returned unchanged
+func withResultLabel(d ResultData, name, value string) ResultData {
+	switch d.Type {
+	case ResultTypeVector:
+		vector := make(Vector, len(d.Vector))
+		for i, s := range d.Vector {
+			vector[i] = Sample{Metric: withLabel(s.Metric, name, value), Value: s.Value}
+		}
+		d.Vector = vector
+	case ResultTypeMatrix:
+		matrix := make(Matrix, len(d.Matrix))
+		for i, s := range d.Matrix {
+			matrix[i] = Series{Metric: withLabel(s.Metric, name, value), Values: s.Values}
+		}
+		d.Matrix = matrix
+	}
+	return d
+}
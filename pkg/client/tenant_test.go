@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestValidateTenant(t *testing.T) {
+	for _, tenant := range []string{"team-a", "team_a.prod", "a|b|c", "(x)*'!"} {
+		if err := ValidateTenant(tenant); err != nil {
+			t.Errorf("%q: unexpected error: %v", tenant, err)
+		}
+	}
+	for _, tenant := range []string{"", "a||b", "..", "team/a", "team a", string(make([]byte, 151))} {
+		if err := ValidateTenant(tenant); err == nil {
+			t.Errorf("%q: expected error, got nil", tenant)
+		}
+	}
+}
+
+func TestReadBackends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backends.yaml")
+	content := "prometheus_backends:\n" +
+		"  - http://localhost:9090\n" +
+		"  - url: http://cortex:8080/prometheus\n" +
+		"    name: cortex\n" +
+		"    tenant: team-a|team-b\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write backends file: %v", err)
+	}
+
+	backends, err := ReadBackends(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Backend{
+		{URL: "http://localhost:9090"},
+		{Name: "cortex", URL: "http://cortex:8080/prometheus", Tenant: "team-a|team-b"},
+	}
+	if !reflect.DeepEqual(backends, want) {
+		t.Errorf("got %+v, want %+v", backends, want)
+	}
+
+	if err := os.WriteFile(path, []byte("prometheus_backends:\n  - url: http://cortex\n    tenant: team/a\n"), 0o600); err != nil {
+		t.Fatalf("failed to write backends file: %v", err)
+	}
+	if _, err := ReadBackends(path); err == nil {
+		t.Error("expected error for invalid tenant, got nil")
+	}
+}
+
+func TestClient_TenantHeader(t *testing.T) {
+	tenants := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenants <- r.Header.Get(TenantHeader)
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBackends(Backend{Name: "cortex", URL: ts.URL, Tenant: "a|b"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.QueryPrometheus(context.Background(), "cortex", "up"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := <-tenants; got != "a|b" {
+		t.Errorf("expected federated tenant header, got %q", got)
+	}
+
+	if _, err := New(WithBackends(Backend{URL: ts.URL, Tenant: "a b"})); err == nil {
+		t.Error("expected error for invalid tenant, got nil")
+	}
+
+	// a tenant given with the query overrides the backend's
+	if _, err := c.Query(context.Background(), QueryData{Query: "up", Backends: []string{"cortex"}, Tenant: "c"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := <-tenants; got != "c" {
+		t.Errorf("expected the query tenant header, got %q", got)
+	}
+	if _, err := c.Query(context.Background(), QueryData{Query: "up", Tenant: "c d"}); err == nil {
+		t.Error("expected error for invalid query tenant, got nil")
+	}
+}
+
+func TestClient_QueryTenants(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get(TenantHeader))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"up"},"value":[1700000000,"1"]}]}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBackends(Backend{URL: ts.URL, Tenant: "ignored"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	merged, err := c.Query(context.Background(), QueryData{Query: "up", Tenants: []string{"team-a", "team-b"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sort.Strings(seen)
+	if !reflect.DeepEqual(seen, []string{"team-a", "team-b"}) {
+		t.Errorf("expected one request per tenant, got %v", seen)
+	}
+	if len(merged.Data.Vector) != 2 {
+		t.Fatalf("expected a series per tenant, got %+v", merged.Data.Vector)
+	}
+	for i, tenant := range []string{"team-a", "team-b"} {
+		if got := merged.Data.Vector[i].Metric[TenantLabel]; got != tenant {
+			t.Errorf("series %d: expected %s=%q, got %q", i, TenantLabel, tenant, got)
+		}
+		if got := merged.Backends[i].Backend; got != tenant+"@"+ts.URL {
+			t.Errorf("backend %d: unexpected name %q", i, got)
+		}
+	}
+
+	if _, err := c.Query(context.Background(), QueryData{Query: "up", Tenants: []string{"bad tenant"}}); err == nil {
+		t.Error("expected error for invalid tenant, got nil")
+	}
+}
@@ -97,10 +97,7 @@ func TestPrometheusAPI(t *testing.T) {
 		t.Fatalf("Error querying Prometheus containers: %v", err)
 	}
 
-	var merged struct {
-		Status string            `json:"status"`
-		Data   []json.RawMessage `json:"data"`
-	}
+	var merged client.MergedResponse
 	if err := json.Unmarshal(output, &merged); err != nil {
 		t.Fatalf("Failed to unmarshal merged response: %v", err)
 	}
@@ -109,8 +106,8 @@ func TestPrometheusAPI(t *testing.T) {
 		t.Fatalf("Expected status 'success', got: %s", merged.Status)
 	}
 
-	if len(merged.Data) != 2 {
-		t.Fatalf("Expected data from 2 containers, got: %d", len(merged.Data))
+	if len(merged.Data.Vector) != 2 {
+		t.Fatalf("Expected one up series from each of 2 containers, got: %d", len(merged.Data.Vector))
 	}
 
 	t.Logf("Test passed: received merged response from both Prometheus containers. Output:\n%s", string(output))
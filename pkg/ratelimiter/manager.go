@@ -1,6 +1,7 @@
 package ratelimiter
 
 import (
+	"context"
 	"log"
 	"sync/atomic"
 	"time"
@@ -49,6 +50,10 @@ func NewManager(conf *Config) *Manager {
 }
 
 func (m *Manager) Acquire() (*Token, error) {
+	return m.AcquireContext(context.Background())
+}
+
+func (m *Manager) AcquireContext(ctx context.Context) (*Token, error) {
 	go func() {
 		m.inChan <- struct{}{}
 	}()
@@ -59,6 +64,13 @@ func (m *Manager) Acquire() (*Token, error) {
 		return token, nil
 	case err := <-m.errorChan:
 		return nil, err
+	case <-ctx.Done():
+		// The request is already queued, so the token it produces is
+		// released straight away rather than left for another caller
+		go func() {
+			m.Release(<-m.outChan)
+		}()
+		return nil, ctx.Err()
 	}
 }
 
@@ -1,11 +1,14 @@
 package ratelimiter
 
 import (
+	"context"
 	"time"
 )
 
 type RateLimiter interface {
 	Acquire() (*Token, error)
+	// AcquireContext is like Acquire but gives up waiting once ctx is done
+	AcquireContext(ctx context.Context) (*Token, error)
 	Release(*Token)
 }
 
@@ -1,6 +1,7 @@
 package ratelimiter
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"sync"
@@ -262,3 +263,40 @@ func TestRateLimitersAcquireTokensAfterRelease(t *testing.T) {
 		}
 	}
 }
+
+func TestRateLimiterAcquireContextCancelled(t *testing.T) {
+	conf := &Config{
+		Limit:            1,
+		TokenResetsAfter: 0, // No reset for this test
+	}
+
+	rl, err := NewMaxConcurrencyRateLimiter(conf)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	token1, err := rl.Acquire()
+	if err != nil {
+		t.Fatalf("expected to acquire token, got error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	token2, err := rl.AcquireContext(ctx)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected %v while the limit is reached, got %v", context.DeadlineExceeded, err)
+	}
+	if token2 != nil {
+		t.Fatal("expected no token after the context expired")
+	}
+
+	rl.Release(token1)
+
+	token3, err := rl.AcquireContext(context.Background())
+	if err != nil {
+		t.Fatalf("expected to acquire token after release, got error: %v", err)
+	}
+	if token3 == nil {
+		t.Fatal("expected a valid token, got nil")
+	}
+}